@@ -1,120 +1,148 @@
-package main // 声明 main 包，表明当前是一个可执行程序
+// Package backpack_interface 封装了 Backpack 交易所的 REST API。
+package backpack_interface
 
 import (
 	"bytes"
-	"crypto/ed25519"
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-var baseURL = "https://api.backpack.exchange"
-
-var client = &http.Client{
-	Timeout: 6 * time.Second,
-}
+// DefaultBaseURL 为 Backpack REST API 的默认地址
+const DefaultBaseURL = "https://api.backpack.exchange"
 
+// Key 为账户的 API 密钥对
 type Key struct {
-	apiKey string
-	secret string
+	APIKey string
+	Secret string
+}
+
+// Client 为 Backpack REST API 客户端
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Key        Key
+	Signer     *Signer         // 为空时只能调用公开接口
+	Limiter    *RateLimiter    // 为空时不限流，可在多个 Client 之间共享
+	Retry      RetryPolicy     // 为空时不重试
+	Markets    *MarketRegistry // 为空时下单前不按市场限制校验
+}
+
+// NewClient 使用默认地址和超时创建客户端。
+// key.Secret 为空时不创建 Signer，只能调用公开接口。
+func NewClient(key Key, opts ...SignerOption) (*Client, error) {
+	c := &Client{
+		BaseURL: DefaultBaseURL,
+		HTTPClient: &http.Client{
+			Timeout: 6 * time.Second,
+		},
+		Key:     key,
+		Limiter: NewRateLimiter(DefaultPublicRateLimit, DefaultSignedRateLimit),
+		Retry:   DefaultRetryPolicy,
+	}
+	c.Markets = NewMarketRegistry(c, DefaultMarketsTTL)
+	if key.Secret != "" {
+		signer, err := NewSigner(key.APIKey, key.Secret, opts...)
+		if err != nil {
+			return nil, err
+		}
+		c.Signer = signer
+	}
+	return c, nil
 }
 
 // k线
 type Klines struct {
-	symbol    string
-	interval  string
-	startTime int
-	endTime   int
+	Symbol    string
+	Interval  KlineInterval
+	StartTime int
+	EndTime   int
 }
 
 // 获取历史交易
 type HistoryTrades struct {
-	symbol string
-	limit  int
-	offset int
+	Symbol string
+	Limit  int
+	Offset int
 }
 
-// 存取历史
+// 存取历史，From 和 To 为毫秒时间戳，为 0 时不限制
 type DepositeHistory struct {
-	key    Key
-	limit  int
-	offset int
+	From   int
+	To     int
+	Limit  int
+	Offset int
 }
 
 // 请求提取
 type RequestWithdraw struct {
-	key            Key
-	address        string
-	blockchain     string
-	clientId       string
-	quantity       string
-	symbol         string
-	twoFactorToken string
-	subaccountId   string
+	Address        string
+	Blockchain     string
+	ClientId       string
+	Quantity       Decimal
+	Symbol         string
+	TwoFactorToken string
 }
 
 // 订单历史
 type OrderHistory struct {
-	key     Key
-	orderId string
-	symbol  string
-	offset  int
-	limit   int
+	OrderId string
+	Symbol  string
+	Offset  int
+	Limit   int
 }
 
-// 填充的订单历史
+// 填充的订单历史，From 和 To 为毫秒时间戳，为 0 时不限制
 type FillHistory struct {
-	key     Key
-	orderId string
-	from    int
-	to      int
-	symbol  string
-	limit   int
-	offset  int
+	OrderId string
+	From    int
+	To      int
+	Symbol  string
+	Limit   int
+	Offset  int
 }
 
-// 提取历史记录
+// 提取历史记录，From 和 To 为毫秒时间戳，为 0 时不限制
 type WithdrawHistory struct {
-	key    Key
-	limit  int
-	offset int
+	From   int
+	To     int
+	Limit  int
+	Offset int
 }
 
-// 打开订单记录
+// 打开订单记录，OrderId 和 ClientId 二选一，为空时不发送
 type OpenOrder struct {
-	key      Key
-	clientId int
-	orderId  string
-	symbol   string
+	ClientId uint32
+	OrderId  string
+	Symbol   string
 }
 
-// 创建订单
+// 创建订单，可用 LimitOrder、MarketOrder 和 MarketOrderQuote 构造。
+// 为零值的可选字段不会发送，也不参与签名。
 type CreateOrder struct {
-	key                 Key
-	clientId            string
-	orderType           string
-	postOnly            bool
-	price               float64
-	quantity            float64
-	quoteQuantity       float64
-	selfTradePrevention string
-	side                string
-	symbol              string
-	timeInForce         string
-	triggerPrice        float64
-}
-
-// 取消打开的订单
+	ClientId            uint32 // 为 0 时不发送
+	OrderType           OrderType
+	PostOnly            bool
+	Price               Decimal
+	Quantity            Decimal
+	QuoteQuantity       Decimal
+	SelfTradePrevention SelfTradePrevention
+	Side                Side
+	Symbol              string
+	TimeInForce         TimeInForce
+	TriggerPrice        Decimal
+}
+
+// 取消打开的订单，OrderId 和 ClientId 二选一，为空时不发送
 type CancelTokenOrder struct {
-	key      Key
-	clientId int
-	orderId  string
-	symbol   string
+	ClientId uint32
+	OrderId  string
+	Symbol   string
 }
 
 func convertMap(mapInterface map[string]interface{}) map[string]string {
@@ -126,6 +154,8 @@ func convertMap(mapInterface map[string]interface{}) map[string]string {
 			mapString[key] = v
 		case int:
 			mapString[key] = strconv.Itoa(v)
+		case Decimal:
+			mapString[key] = v.String()
 		// 添加更多的类型转换，根据需要
 		default:
 			mapString[key] = fmt.Sprintf("%v", value)
@@ -135,916 +165,562 @@ func convertMap(mapInterface map[string]interface{}) map[string]string {
 	return mapString
 }
 
-func getRequest(
-	url string,
-	head map[string]string,
-	params map[string]interface{},
-) (map[string]interface{}, error) {
-	reqURL := baseURL + url
+// request 描述一次 REST 调用
+type request struct {
+	method       string
+	path         string
+	params       interface{}   // GET 时为查询参数，其他方法为 JSON 请求体
+	instructions []Instruction // 需要签名的指令，为空时为公开接口
+	idempotent   bool          // 为 true 时失败后按 Client.Retry 重试
+}
 
-	paramString := convertMap(params)
+func (r request) signed() bool {
+	return len(r.instructions) > 0
+}
+
+// doRequest 发送请求，幂等请求失败后按 Client.Retry 重试
+func (c *Client) doRequest(ctx context.Context, r request) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		data, err := c.send(ctx, r)
+		if err == nil || !r.idempotent || c.Retry == nil || ctx.Err() != nil {
+			return data, err
+		}
+		delay, ok := c.Retry.Retry(attempt, err)
+		if !ok {
+			return nil, err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+	}
+}
+
+// send 等待限流后签名并发送一次请求，返回原始响应体，非 2xx 响应返回 *APIError。
+// 签名在限流等待之后生成，每次重试都重新签名，避免时间窗口过期。
+func (c *Client) send(ctx context.Context, r request) ([]byte, error) {
+	if r.signed() && c.Signer == nil {
+		return nil, ErrMissingCredentials
+	}
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx, r.signed(), 1); err != nil {
+			return nil, fmt.Errorf("backpack: %s %s: %w", r.method, r.path, err)
+		}
+	}
+
+	reqURL := c.BaseURL + r.path
+
+	var body io.Reader
+	if r.method != http.MethodGet && r.params != nil {
+		jsonParams, err := json.Marshal(r.params)
+		if err != nil {
+			return nil, fmt.Errorf("backpack: %s %s: encode params: %w", r.method, r.path, err)
+		}
+		body = bytes.NewBuffer(jsonParams)
+	}
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, r.method, reqURL, body)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("backpack: %s %s: %w", r.method, r.path, err)
 	}
 	// 设置请求头
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	for k, v := range head {
-		req.Header.Set(k, v)
+	if r.signed() {
+		sig, err := c.Signer.SignBatch(r.instructions)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range sig.Headers() {
+			req.Header.Set(k, v)
+		}
 	}
 
 	// 设置查询参数
-	q := req.URL.Query()
-	for k, v := range paramString {
-		q.Add(k, v)
+	if r.method == http.MethodGet {
+		q := req.URL.Query()
+		query, _ := r.params.(map[string]interface{})
+		for k, v := range convertMap(query) {
+			q.Add(k, v)
+		}
+		req.URL.RawQuery = q.Encode()
 	}
-	req.URL.RawQuery = q.Encode()
 
 	// 发起请求
-	resp, err := client.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("backpack: %s %s: %w", r.method, r.path, err)
 	}
 	defer resp.Body.Close()
 
-	// 解码响应体
-	var result map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("backpack: %s %s: read body: %w", r.method, r.path, err)
 	}
-
-	return result, nil
-}
-
-func postRequest(
-	url string,
-	head map[string]string,
-	proxy map[string]string,
-	params map[string]interface{},
-) (map[string]interface{}, error) {
-	reqURL := baseURL + url
-
-	jsonParams, err := json.Marshal(params)
-	if err != nil {
-		panic(err)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := newAPIError(resp.StatusCode, r.path, data)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			apiErr.RetryAfter = parseRetryAfter(resp.Header, time.Now())
+			if c.Limiter != nil {
+				c.Limiter.Backoff(apiErr.RetryAfter)
+			}
+		}
+		return nil, apiErr
 	}
+	return data, nil
+}
 
-	req, err := http.NewRequest("POST", reqURL, bytes.NewBuffer(jsonParams))
+// decodeRequest 发送请求并将响应体解码到 out
+func (c *Client) decodeRequest(ctx context.Context, r request, out interface{}) error {
+	data, err := c.doRequest(ctx, r)
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	for k, v := range head {
-		req.Header.Set(k, v)
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("backpack: %s %s: decode response: %w", r.method, r.path, err)
 	}
+	return nil
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		panic(err)
+// newRequest 创建请求，instruction 非空时用 params 签名。
+// 除 POST 外的请求视为幂等。
+func newRequest(method, url, instruction string, params map[string]interface{}) request {
+	r := request{method: method, path: url, idempotent: method != http.MethodPost}
+	if params != nil {
+		r.params = params
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		panic(err)
+	if instruction != "" {
+		r.instructions = []Instruction{{Name: instruction, Params: params}}
 	}
-
-	return result, nil
+	return r
 }
 
-func deleteRequest(
+func (c *Client) getRequest(
+	ctx context.Context,
 	url string,
-	head map[string]string,
-	proxy map[string]string,
+	instruction string,
 	params map[string]interface{},
-) (map[string]interface{}, error) {
-	reqURL := baseURL + url
-
-	jsonParams, err := json.Marshal(params)
-	if err != nil {
-		panic(err)
-	}
-
-	req, err := http.NewRequest("DELETE", reqURL, bytes.NewBuffer(jsonParams))
-	if err != nil {
-		panic(err)
-	}
-
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	for k, v := range head {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
+	out interface{},
+) error {
+	return c.decodeRequest(ctx, newRequest(http.MethodGet, url, instruction, params), out)
+}
 
-	var result map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		panic(err)
-	}
+func (c *Client) postRequest(
+	ctx context.Context,
+	url string,
+	instruction string,
+	params map[string]interface{},
+	out interface{},
+) error {
+	return c.decodeRequest(ctx, newRequest(http.MethodPost, url, instruction, params), out)
+}
 
-	return result, nil
+func (c *Client) deleteRequest(
+	ctx context.Context,
+	url string,
+	instruction string,
+	params map[string]interface{},
+	out interface{},
+) error {
+	return c.decodeRequest(ctx, newRequest(http.MethodDelete, url, instruction, params), out)
 }
 
 // 检索交易所支持的所有资产。
-func getAssets() ([]map[string]interface{}, error) {
+func (c *Client) GetAssets(ctx context.Context) ([]Asset, error) {
 	url := "/api/v1/assets"
-	data, err := getRequest(url, nil, nil)
-	if err != nil {
-		panic(err)
-	}
-
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
+	var rst []Asset
+	if err := c.getRequest(ctx, url, "", nil, &rst); err != nil {
 		return nil, err
 	}
-
-	rst := make([]map[string]interface{}, len(data))
-	for i, _ := range data {
-		v, _ := data[i].(map[string]interface{})
-		tokensData, _ := v["tokens"].([]interface{})
-
-		tokens := make([]map[string]interface{}, len(v["tokens"].([]interface{})))
-		for j, token := range tokensData {
-			tokenInfo := token.(map[string]interface{})
-			tokens[j] = map[string]interface{}{
-				"blockchain":        tokenInfo["blockchain"],
-				"depositEnabled":    tokenInfo["depositEnabled"],
-				"minimumDeposit":    tokenInfo["minimumDeposit"],
-				"withdrawEnabled":   tokenInfo["withdrawEnabled"],
-				"minimumWithdrawal": tokenInfo["minimumWithdrawal"],
-				"maximumWithdrawal": tokenInfo["maximumWithdrawal"],
-				"withdrawalFee":     tokenInfo["withdrawalFee"],
-			}
-		}
-		// 将字符串转换为整数
-		num, err := strconv.Atoi(i)
-		if err != nil {
-			fmt.Println("转换失败:")
-			panic(err)
-		}
-		rst[num] = map[string]interface{}{
-			"symbol": v["symbol"],
-			"tokens": tokens,
-		}
-	}
-	fmt.Println("Assets:", rst)
 	return rst, nil
 }
 
 // 检索交易所支持的所有市场。
-func getMarkets() ([]map[string]interface{}, error) {
+func (c *Client) GetMarkets(ctx context.Context) ([]Market, error) {
 	url := "/api/v1/markets"
-	data, err := getRequest(url, nil, nil)
-	if err != nil {
-		panic(err)
-	}
-
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
+	var rst []Market
+	if err := c.getRequest(ctx, url, "", nil, &rst); err != nil {
 		return nil, err
 	}
-	rst := make([]map[string]interface{}, len(data))
-	for i, item := range data {
-		v := item.(map[string]interface{})
-		filters := v["filters"].(map[string]interface{})
-		// 将字符串转换为整数
-		num, err := strconv.Atoi(i)
-		if err != nil {
-			fmt.Println("转换失败:", err)
-		}
-		rst[num] = map[string]interface{}{
-			"symbol":      v["symbol"],
-			"baseSymbol":  v["baseSymbol"],
-			"quoteSymbol": v["quoteSymbol"],
-			"filters": map[string]interface{}{
-				"price": map[string]interface{}{
-					"minPrice": filters["minPrice"],
-					"maxPrice": filters["maxPrice"],
-					"tickSize": filters["tickSize"],
-				},
-				"quantity": map[string]interface{}{
-					"minQuantity": filters["minQuantity"],
-					"maxQuantity": filters["maxQuantity"],
-					"stepSize":    filters["stepSize"],
-				},
-				"leverage": map[string]interface{}{
-					"minLeverage": filters["minLeverage"],
-					"maxLeverage": filters["maxLeverage"],
-					"stepSize":    filters["stepSize"],
-				},
-			},
-		}
-	}
-	fmt.Println("Markets:", rst)
 	return rst, nil
 }
 
 // 检索过去24小时内给定市场代码的汇总统计数据。
-func getTicker(symbol string) (map[string]interface{}, error) {
+func (c *Client) GetTicker(ctx context.Context, symbol string) (*Ticker, error) {
 	url := "/api/v1/ticker"
 	params := map[string]interface{}{"symbol": symbol}
-	data, err := getRequest(url, nil, params)
-	if err != nil {
-		panic(err)
-	}
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
+	var rst Ticker
+	if err := c.getRequest(ctx, url, "", params, &rst); err != nil {
 		return nil, err
 	}
-	fmt.Println("Ticker:", data)
-	return data, nil
+	return &rst, nil
 }
 
 // 检索过去24小时所有市场代码的汇总统计数据。
-func getTickers() ([]map[string]interface{}, error) {
+func (c *Client) GetTickers(ctx context.Context) ([]Ticker, error) {
 	url := "/api/v1/tickers"
-	data, err := getRequest(url, nil, nil)
-	if err != nil {
-		panic(err)
-	}
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
+	var rst []Ticker
+	if err := c.getRequest(ctx, url, "", nil, &rst); err != nil {
 		return nil, err
 	}
-
-	var rst []map[string]interface{}
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		ticker := map[string]interface{}{
-			"symbol":             v["symbol"],
-			"firstPrice":         v["firstPrice"],
-			"lastPrice":          v["lastPrice"],
-			"priceChange":        v["priceChange"],
-			"priceChangePercent": v["priceChangePercent"],
-			"high":               v["high"],
-			"low":                v["low"],
-			"volume":             v["volume"],
-			"quoteVolume":        v["quoteVolume"],
-			"trades":             v["trades"],
-		}
-		rst = append(rst, ticker)
-	}
-
-	fmt.Println("Tickers:", rst)
 	return rst, nil
 }
 
 // 检索给定市场符号的订单深度。
-func getDepth(symbol string) (map[string]interface{}, error) {
+func (c *Client) GetDepth(ctx context.Context, symbol string) (*Depth, error) {
 	url := "/api/v1/depth"
 	params := map[string]interface{}{"symbol": symbol}
-	data, err := getRequest(url, nil, params)
-	if err != nil {
-		panic(err)
-	}
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
+	var rst Depth
+	if err := c.getRequest(ctx, url, "", params, &rst); err != nil {
 		return nil, err
 	}
-	fmt.Println(data)
-	return data, nil
+	return &rst, nil
 }
 
 // 获取给定市场代码的k线
-func getKLines(
+func (c *Client) GetKLines(
+	ctx context.Context,
 	k Klines,
-) ([]map[string]interface{}, error) {
+) ([]Kline, error) {
 	url := "/api/v1/klines"
+	if !k.Interval.Valid() {
+		return nil, fmt.Errorf("backpack: invalid kline interval %q", k.Interval)
+	}
 	params := map[string]interface{}{
-		"symbol":    k.symbol,
-		"interval":  k.interval,
-		"startTime": k.startTime,
-		"endTime":   k.endTime,
+		"symbol":    k.Symbol,
+		"interval":  k.Interval,
+		"startTime": k.StartTime,
 	}
-
-	data, err := getRequest(url, nil, params)
-	if err != nil {
-		panic(err)
+	if k.EndTime != 0 {
+		params["endTime"] = k.EndTime
 	}
 
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
+	var rst []Kline
+	if err := c.getRequest(ctx, url, "", params, &rst); err != nil {
 		return nil, err
 	}
-
-	var kLines []map[string]interface{}
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		kLine := map[string]interface{}{
-			"start":  v["start"],
-			"open":   v["open"],
-			"high":   v["high"],
-			"low":    v["low"],
-			"close":  v["close"],
-			"end":    v["end"],
-			"volume": v["volume"],
-			"trades": v["trades"],
-		}
-		kLines = append(kLines, kLine)
-	}
-
-	return kLines, nil
+	return rst, nil
 }
 
 /** ********************************** system ******************************** */
 // 得到系统状态
-func getStatus() (map[string]interface{}, error) {
+func (c *Client) GetStatus(ctx context.Context) (*Status, error) {
 	url := "/api/v1/status"
-	data, err := getRequest(url, nil, nil)
-	if err != nil {
-		panic(err)
-	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
+	var rst Status
+	if err := c.getRequest(ctx, url, "", nil, &rst); err != nil {
 		return nil, err
 	}
-	return data, err
+	return &rst, nil
 }
 
-// 得到ping
-func getPing() (map[string]interface{}, error) {
+// 得到ping，正常时返回 "pong"
+func (c *Client) GetPing(ctx context.Context) (string, error) {
 	url := "/api/v1/ping"
-	data, err := getRequest(url, nil, nil)
+	data, err := c.doRequest(ctx, newRequest(http.MethodGet, url, "", nil))
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
-		return nil, err
-	}
-	return data, err
+	return strings.TrimSpace(string(data)), nil
 }
 
 // 得到当前系统时间
-func getSystemTime() (map[string]interface{}, error) {
+func (c *Client) GetSystemTime(ctx context.Context) (time.Time, error) {
 	url := "/api/v1/time"
-	data, err := getRequest(url, nil, nil)
-	if err != nil {
-		panic(err)
+	var ms int64
+	if err := c.getRequest(ctx, url, "", nil, &ms); err != nil {
+		return time.Time{}, err
 	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
-		return nil, err
-	}
-	return data, err
+	return time.UnixMilli(ms), nil
 }
 
 /** ********************************** 获取交易信息 ******************************** */
 // 获取最近的交易
-func getRecentTrades(symbol string, limit int) ([]map[string]interface{}, error) {
+func (c *Client) GetRecentTrades(ctx context.Context, symbol string, limit int) ([]Trade, error) {
 	url := "/api/v1/trades"
 	params := map[string]interface{}{
 		"symbol": symbol,
 		"limit":  limit,
 	}
-	data, err := getRequest(url, nil, params)
-	if err != nil {
-		panic(err)
-	}
-
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
+	var rst []Trade
+	if err := c.getRequest(ctx, url, "", params, &rst); err != nil {
 		return nil, err
 	}
-
-	rst := make([]map[string]interface{}, len(data))
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		assetInfo := map[string]interface{}{
-			"id":            v["id"].(int64),
-			"price":         v["price"].(string),
-			"quantity":      v["quantity"].(string),
-			"quoteQuantity": v["quoteQuantity"].(string),
-			"timestamp":     v["timestamp"].(int64),
-			"isBuyerMaker":  v["isBuyerMaker"].(bool),
-		}
-		rst = append(rst, assetInfo)
-	}
-	fmt.Println("Recent Trades:", rst)
 	return rst, nil
 }
 
 // 获取历史交易
-func getHistoricalTrades(
+func (c *Client) GetHistoricalTrades(
+	ctx context.Context,
 	h HistoryTrades,
-) ([]map[string]interface{}, error) {
+) ([]Trade, error) {
 	url := "/api/v1/trades/history"
 	params := map[string]interface{}{
-		"symbol": h.symbol,
-		"limit":  h.limit,
-		"offset": h.limit,
+		"symbol": h.Symbol,
+		"limit":  h.Limit,
+		"offset": h.Offset,
 	}
-	data, err := getRequest(url, nil, params)
-	if err != nil {
-		panic(err)
-	}
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
+	var rst []Trade
+	if err := c.getRequest(ctx, url, "", params, &rst); err != nil {
 		return nil, err
 	}
-
-	rst := make([]map[string]interface{}, len(data))
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		assetInfo := map[string]interface{}{
-			"id":            v["id"].(int64),
-			"price":         v["price"].(string),
-			"quantity":      v["quantity"].(string),
-			"quoteQuantity": v["quoteQuantity"].(string),
-			"timestamp":     v["timestamp"].(int64),
-			"isBuyerMaker":  v["isBuyerMaker"].(bool),
-		}
-		rst = append(rst, assetInfo)
-	}
-	fmt.Println("History Trades:", rst)
 	return rst, nil
 }
 
-// capital
-func generateSignature(
-	instruction,
-	apikey,
-	secret string,
-	payload map[string]interface{},
-) map[string]string {
-	timestamp := fmt.Sprintf("%d", time.Now().UnixNano()/int64(time.Millisecond))
-	window := "10000"
-
-	payloadString := convertMap(payload)
-
-	data := make(map[string]string)
-	data["instruction"] = instruction
-	for k, v := range payloadString {
-		data[k] = v
-	}
-	data["timestamp"] = timestamp
-	data["window"] = window
-
-	// Sort data by keys
-	keys := make([]string, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	var sortedData []string
-	for _, k := range keys {
-		sortedData = append(sortedData, k+"="+data[k])
-	}
-
-	// Create a string to sign
-	signString := strings.Join(sortedData, "&")
-
-	// Sign the string using the private key
-	privateKeyBytes, err := base64.StdEncoding.DecodeString(secret)
-	if err != nil {
-		panic(err)
-	}
-	privateKey := ed25519.PrivateKey(privateKeyBytes)
-	signature := ed25519.Sign(privateKey, []byte(signString))
-
-	// Encode the signature in base64
-	signatureB64 := base64.StdEncoding.EncodeToString(signature)
-
-	head := map[string]string{
-		"X-API-Key":   apikey,
-		"X-Signature": signatureB64,
-		"X-Timestamp": timestamp,
-		"X-Window":    window,
-	}
-
-	return head
-}
-
+/** ********************************** capital ******************************** */
 // 获取账户余额和余额状态
-func getBalances(
-	key Key,
-) (map[string]interface{}, error) {
+func (c *Client) GetBalances(ctx context.Context) (map[string]Balance, error) {
 	url := "/api/v1/capital"
 
-	headers := generateSignature("balanceQuery", key.apiKey, key.secret, nil)
-
-	data, err := getRequest(url, headers, nil)
-	if err != nil {
-		panic(err)
-	}
-
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
+	instruction := "balanceQuery"
+	var rst map[string]Balance
+	if err := c.getRequest(ctx, url, instruction, nil, &rst); err != nil {
 		return nil, err
 	}
-
-	rst := make(map[string]interface{})
-	for asset, values := range data {
-		v := values.(map[string]interface{})
-		available, _ := strconv.ParseFloat(v["available"].(string), 64)
-		locked, _ := strconv.ParseFloat(v["locked"].(string), 64)
-		staked, _ := strconv.ParseFloat(v["staked"].(string), 64)
-		total := available + locked + staked
-		assetInfo := map[string]interface{}{
-			"available": available,
-			"locked":    locked,
-			"staked":    staked,
-			"total":     total,
-		}
-		rst[asset] = assetInfo
-	}
-	fmt.Println("User Balances:", rst)
 	return rst, nil
 }
 
 // 获取存款历史记录
-func getDepositeHistory(
+func (c *Client) GetDepositeHistory(
+	ctx context.Context,
 	DE DepositeHistory,
-) ([]map[string]interface{}, error) {
+) ([]Deposit, error) {
 	url := "/wapi/v1/capital/deposits"
 	params := map[string]interface{}{
-		"limit":  DE.limit,
-		"offset": DE.offset,
+		"limit":  DE.Limit,
+		"offset": DE.Offset,
 	}
-	headers := generateSignature("depositQueryAll", DE.key.apiKey, DE.key.secret, params)
-	data, err := getRequest(url, headers, params)
-	if err != nil {
-		panic(err)
+	if DE.From != 0 {
+		params["from"] = DE.From
 	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
-		return nil, err
+	if DE.To != 0 {
+		params["to"] = DE.To
 	}
-
-	rst := make([]map[string]interface{}, len(data))
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		assetInfo := map[string]interface{}{
-			"id":                      v["id"].(int32),
-			"toAddress":               v["toAddress"].(string),
-			"fromAddress":             v["fromAddress"].(string),
-			"confirmationBlockNumber": v["confirmationBlockNumber"].(int64),
-			"providerId":              v["providerId"].(string),
-			"source":                  v["source"].(string),
-			"status":                  v["status"].(string),
-			"transactionHash":         v["transactionHash"].(string),
-			"subaccountId":            v["subaccountId"].(int32),
-			"symbol":                  v["symbol"].(string),
-			"quantity":                v["quantity"].(string),
-			"createdAt":               v["createdAt"].(string),
-		}
-		rst = append(rst, assetInfo)
+	instruction := "depositQueryAll"
+	var rst []Deposit
+	if err := c.getRequest(ctx, url, instruction, params, &rst); err != nil {
+		return nil, err
 	}
-	fmt.Println("Deposite History:", rst)
 	return rst, nil
 }
 
 // 获取存款地址
-func getDepositorAddress(
-	key Key,
+func (c *Client) GetDepositorAddress(
+	ctx context.Context,
 	blockchain string,
-) (map[string]interface{}, error) {
+) (*DepositAddress, error) {
 	url := "/wapi/v1/capital/deposit/address"
 	params := map[string]interface{}{"blockchain": blockchain}
-	headers := generateSignature("depositAddressQuery", key.apiKey, key.secret, params)
-	data, err := getRequest(url, headers, params)
-	if err != nil {
-		panic(err)
-	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
+	instruction := "depositAddressQuery"
+	var rst DepositAddress
+	if err := c.getRequest(ctx, url, instruction, params, &rst); err != nil {
 		return nil, err
 	}
-	fmt.Println("Depositor Address:", data)
-	return data, err
+	return &rst, nil
 }
 
 // 获取提款历史记录
-func getWithdrawHistory(
+func (c *Client) GetWithdrawHistory(
+	ctx context.Context,
 	wh WithdrawHistory,
-) ([]map[string]interface{}, error) {
+) ([]Withdrawal, error) {
 	url := "/wapi/v1/capital/withdrawals"
 	params := map[string]interface{}{
-		"limit":  wh.limit,
-		"offset": wh.limit,
+		"limit":  wh.Limit,
+		"offset": wh.Offset,
 	}
-	headers := generateSignature("withdrawalQueryAll", wh.key.apiKey, wh.key.secret, params)
-	data, err := getRequest(url, headers, params)
-	if err != nil {
-		panic(err)
+	if wh.From != 0 {
+		params["from"] = wh.From
 	}
-	if data != nil && data["status"] == "fail" {
-		fmt.Println(data)
-		return nil, err
+	if wh.To != 0 {
+		params["to"] = wh.To
 	}
-
-	rst := make([]map[string]interface{}, len(data))
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		assetInfo := map[string]interface{}{
-			"id":              v["id"].(int32),
-			"blockchain":      v["blockchain"].(string),
-			"clientId":        v["clientId"].(string),
-			"identifier":      v["identifier"].(string),
-			"quantity":        v["quantity"].(string),
-			"fee":             v["fee"].(string),
-			"symbol":          v["symbol"].(string),
-			"status":          v["status"].(string),
-			"subaccountId":    v["subaccountId"].(int32),
-			"toAddress":       v["toAddress"].(string),
-			"transactionHash": v["transactionHash"].(string),
-			"createdAt":       v["createdAt"].(string),
-		}
-		rst = append(rst, assetInfo)
+	instruction := "withdrawalQueryAll"
+	var rst []Withdrawal
+	if err := c.getRequest(ctx, url, instruction, params, &rst); err != nil {
+		return nil, err
 	}
-	fmt.Println("Withdraw History:", rst)
 	return rst, nil
 }
 
 // 请求提款
-func requestWithdrawal(
+func (c *Client) RequestWithdrawal(
+	ctx context.Context,
 	rw RequestWithdraw,
-) (map[string]interface{}, error) {
+) (*Withdrawal, error) {
 	url := "/wapi/v1/capital/withdrawals"
 	params := map[string]interface{}{
-		"address":        rw.address,
-		"blockchain":     rw.blockchain,
-		"clientId":       rw.clientId,
-		"quantity":       rw.quantity,
-		"symbol":         rw.symbol,
-		"twoFactorToken": rw.twoFactorToken,
-	}
-	head := generateSignature("withdraw", rw.key.apiKey, rw.key.secret, params)
-	data, err := postRequest(url, head, nil, params)
-	if err != nil {
-		panic(err)
-	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
+		"address":        rw.Address,
+		"blockchain":     rw.Blockchain,
+		"clientId":       rw.ClientId,
+		"quantity":       rw.Quantity,
+		"symbol":         rw.Symbol,
+		"twoFactorToken": rw.TwoFactorToken,
+	}
+	instruction := "withdraw"
+	var rst Withdrawal
+	if err := c.postRequest(ctx, url, instruction, params, &rst); err != nil {
 		return nil, err
 	}
-	fmt.Println("Request Withdraw:", data)
-	return data, nil
+	return &rst, nil
 }
 
 /** ********************************** 订单历史记录 ******************************** */
 // 获取订单历史记录。
-func getOrderHistory(
+func (c *Client) GetOrderHistory(
+	ctx context.Context,
 	oh OrderHistory,
-) ([]map[string]interface{}, error) {
+) ([]Order, error) {
 	url := "/wapi/v1/history/orders"
 	params := map[string]interface{}{
-		"orderId": oh.orderId,
-		"symbol":  oh.symbol,
-		"limit":   oh.limit,
-		"offset":  oh.offset,
+		"limit":  oh.Limit,
+		"offset": oh.Offset,
 	}
-
-	headers := generateSignature("orderHistoryQueryAll", oh.key.apiKey, oh.key.secret, params)
-	data, err := getRequest(url, headers, params)
-	if err != nil {
-		panic(err)
+	if oh.OrderId != "" {
+		params["orderId"] = oh.OrderId
 	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
-		return nil, err
+	if oh.Symbol != "" {
+		params["symbol"] = oh.Symbol
 	}
-	rst := make([]map[string]interface{}, len(data))
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		assetInfo := map[string]interface{}{
-			"id":                  v["id"].(string),
-			"orderType":           v["orderType"].(string),
-			"symbol":              v["symbol"].(string),
-			"side":                v["side"].(string),
-			"price":               v["price"].(string),
-			"triggerPrice":        v["triggerPrice"].(string),
-			"quantity":            v["quantity"].(string),
-			"quoteQuantity":       v["quoteQuantity"].(string),
-			"timeInForce":         v["timeInForce"].(int32),
-			"selfTradePrevention": v["selfTradePrevention"].(string),
-			"postOnly":            v["postOnly"].(string),
-			"status":              v["status"].(string),
-		}
-		rst = append(rst, assetInfo)
+
+	instruction := "orderHistoryQueryAll"
+	var rst []Order
+	if err := c.getRequest(ctx, url, instruction, params, &rst); err != nil {
+		return nil, err
 	}
-	fmt.Println("Order History:", rst)
 	return rst, nil
 }
 
 // 获取填充订单历史记录
-func getFillHistory(
+func (c *Client) GetFillHistory(
+	ctx context.Context,
 	FH FillHistory,
-) (map[string]interface{}, error) {
+) ([]Fill, error) {
 	url := "/wapi/v1/history/fills"
 	params := map[string]interface{}{
-		"orderId": FH.orderId,
-		"from":    FH.from,
-		"to":      FH.to,
-		"symbol":  FH.symbol,
-		"limit":   FH.limit,
-		"offset":  FH.limit,
-	}
-	headers := generateSignature("fillHistoryQueryAll", FH.key.apiKey, FH.key.secret, params)
-	data, err := getRequest(url, headers, params)
-	if err != nil {
-		panic(err)
+		"limit":  FH.Limit,
+		"offset": FH.Offset,
 	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
-		return nil, err
+	if FH.OrderId != "" {
+		params["orderId"] = FH.OrderId
 	}
-
-	rst := make(map[string]interface{})
-	for asset, values := range data {
-		v := values.(map[string]interface{})
-		assetInfo := map[string]interface{}{
-			"tradeId":   v["tradeId"].(int64),
-			"orderId":   v["orderType"].(string),
-			"symbol":    v["symbol"].(string),
-			"side":      v["side"].(string),
-			"price":     v["price"].(string),
-			"quantity":  v["quantity"].(string),
-			"fee":       v["fee"].(string),
-			"feeSymbol": v["feeSymbol"].(string),
-			"isMaker":   v["isMaker"].(int32),
-			"timestamp": v["timestamp"].(string),
-		}
-		rst[asset] = assetInfo
+	if FH.Symbol != "" {
+		params["symbol"] = FH.Symbol
+	}
+	if FH.From != 0 {
+		params["from"] = FH.From
+	}
+	if FH.To != 0 {
+		params["to"] = FH.To
+	}
+	instruction := "fillHistoryQueryAll"
+	var rst []Fill
+	if err := c.getRequest(ctx, url, instruction, params, &rst); err != nil {
+		return nil, err
 	}
-	fmt.Println("Fill History:", rst)
 	return rst, nil
 }
 
 /** ********************************** 订单相关 ******************************** */
 //得到开仓的订单
-func getTokenOpenOrder(
+func (c *Client) GetTokenOpenOrder(
+	ctx context.Context,
 	o OpenOrder,
-) (map[string]interface{}, error) {
+) (*Order, error) {
 	url := "/api/v1/order"
 	params := map[string]interface{}{
-		"clientId": o.clientId,
-		"symbol":   o.symbol,
-		"orderId":  o.orderId,
+		"symbol": o.Symbol,
 	}
-	headers := generateSignature("orderQuery", o.key.apiKey, o.key.secret, params)
-	data, err := getRequest(url, headers, params)
-	if err != nil {
-		panic(err)
+	if o.OrderId != "" {
+		params["orderId"] = o.OrderId
 	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
+	if o.ClientId != 0 {
+		params["clientId"] = o.ClientId
+	}
+	instruction := "orderQuery"
+	var rst Order
+	if err := c.getRequest(ctx, url, instruction, params, &rst); err != nil {
 		return nil, err
 	}
-	fmt.Println("This Open Order:", data)
-	return data, err
+	return &rst, nil
 }
 
 // 执行订单
-func createOrder(
+func (c *Client) CreateOrder(
+	ctx context.Context,
 	co CreateOrder,
-) (map[string]interface{}, error) {
+) (*Order, error) {
 	url := "/api/v1/order"
 
-	params := map[string]interface{}{
-		"clientId":            co.clientId,
-		"orderType":           co.orderType,
-		"postOnly":            co.postOnly,
-		"price":               co.price,
-		"quantity":            co.quantity,
-		"quoteQuantity":       co.quoteQuantity,
-		"selfTradePrevention": co.selfTradePrevention,
-		"side":                co.side,
-		"symbol":              co.symbol,
-		"timeInForce":         co.timeInForce,
-		"triggerPrice":        co.triggerPrice,
+	if err := c.validateOrder(ctx, co); err != nil {
+		return nil, err
 	}
+	params := co.params()
 
-	head := generateSignature("orderExecute", co.key.apiKey, co.key.secret, params)
-
-	data, err := postRequest(url, head, nil, params)
-	if err != nil {
-		panic(err)
-	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
+	// 带 clientId 的订单可以安全重试
+	r := newRequest(http.MethodPost, url, "orderExecute", params)
+	r.idempotent = co.ClientId != 0
+	var rst Order
+	if err := c.decodeRequest(ctx, r, &rst); err != nil {
 		return nil, err
 	}
-	fmt.Println("create order:", data)
-	return data, nil
+	return &rst, nil
 }
 
-// 检索某个token所有未结订单
-func getTokenOpenAllOrders(
-	key Key,
+// 检索某个token所有未结订单，symbol 为空时检索所有市场
+func (c *Client) GetTokenOpenAllOrders(
+	ctx context.Context,
 	symbol string,
-) ([]map[string]interface{}, error) {
+) ([]Order, error) {
 	url := "/api/v1/orders"
-	params := map[string]interface{}{"symbol": symbol}
-	headers := generateSignature("orderQueryAll", key.apiKey, key.secret, params)
-	data, err := getRequest(url, headers, params)
-	if err != nil {
-		panic(err)
+	params := map[string]interface{}{}
+	if symbol != "" {
+		params["symbol"] = symbol
 	}
-
-	rst := make([]map[string]interface{}, len(data))
-	for _, values := range data {
-		v := values.(map[string]interface{})
-		assetInfo := map[string]interface{}{
-			"orderType":             v["orderType"].(string),
-			"id":                    v["id"].(string),
-			"clientId":              v["clientId"].(uint32),
-			"symbol":                v["symbol"].(string),
-			"side":                  v["side"].(string),
-			"quantity":              v["quantity"].(string),
-			"executedQuantity":      v["executedQuantity"].(string),
-			"quoteQuantity":         v["quoteQuantity"].(string),
-			"executedQuoteQuantity": v["executedQuoteQuantity"].(string),
-			"triggerPrice":          v["triggerPrice"].(string),
-			"timeInForce":           v["timeInForce"].(string),
-			"selfTradePrevention":   v["selfTradePrevention"].(string),
-			"status":                v["status"].(string),
-			"createdAt":             v["createdAt"].(int64),
-		}
-		rst = append(rst, assetInfo)
+	instruction := "orderQueryAll"
+	var rst []Order
+	if err := c.getRequest(ctx, url, instruction, params, &rst); err != nil {
+		return nil, err
 	}
-	fmt.Println("Token All OpenOrders:", rst)
 	return rst, nil
 }
 
 // 从订单簿中取消某个未结订单。
-func cancelOpenOrder(
+func (c *Client) CancelOpenOrder(
+	ctx context.Context,
 	CTO CancelTokenOrder,
-) (map[string]interface{}, error) {
+) (*Order, error) {
 	url := "/api/v1/order"
 	params := map[string]interface{}{
-		"clientId": CTO.clientId,
-		"orderId":  CTO.orderId,
-		"symbol":   CTO.symbol,
+		"symbol": CTO.Symbol,
 	}
-	head := generateSignature("orderCancel", CTO.key.apiKey, CTO.key.secret, params)
-	//转换
-	paramsInterface := make(map[string]interface{})
-	for key, value := range params {
-		paramsInterface[key] = value
+	if CTO.OrderId != "" {
+		params["orderId"] = CTO.OrderId
 	}
-	data, err := deleteRequest(url, nil, head, paramsInterface)
-	if err != nil {
-		panic(err)
+	if CTO.ClientId != 0 {
+		params["clientId"] = CTO.ClientId
 	}
-	if status, ok := data["status"]; ok && status == "fail" {
-		fmt.Println(data)
-		return nil, nil
+	instruction := "orderCancel"
+	var rst Order
+	if err := c.deleteRequest(ctx, url, instruction, params, &rst); err != nil {
+		return nil, err
 	}
-	fmt.Println("Cancel This OpenOrder:", data)
-	return data, nil
+	return &rst, nil
 }
 
 // 从订单簿中取消所有未结订单。
-func cancelOpenOrders(
-	key Key,
+func (c *Client) CancelOpenOrders(
+	ctx context.Context,
 	symbol string,
-	proxy map[string]string,
-) (map[string]interface{}, error) {
+) ([]Order, error) {
 	url := "/api/v1/orders"
 	params := map[string]interface{}{"symbol": symbol}
 
-	head := generateSignature("orderCancelAll", key.apiKey, key.secret, params)
-	//转换
-	paramsInterface := make(map[string]interface{})
-	for key, value := range params {
-		paramsInterface[key] = value
-	}
-
-	count := 0
-	for {
-		if count > 3 {
-			return nil, nil
-		}
-		data, err := deleteRequest(url, proxy, head, paramsInterface)
-		if err != nil {
-			return nil, err
-		}
-		if data != nil && data["status"] == "fail" {
-			fmt.Println("retry cancel orders")
-			count++
-		} else {
-			return data, nil
-		}
-	}
-}
-
-func main() {
-	systemTime, err := getSystemTime()
-	if err != nil {
-		panic(err)
+	instruction := "orderCancelAll"
+	var rst []Order
+	if err := c.deleteRequest(ctx, url, instruction, params, &rst); err != nil {
+		return nil, err
 	}
-
-	// 输出系统时间
-	fmt.Println("Assets:", systemTime)
+	return rst, nil
 }
@@ -0,0 +1,121 @@
+package backpack_interface
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultClockSyncSamples 为每次同步时请求 /api/v1/time 的次数
+const DefaultClockSyncSamples = 3
+
+// ClockStats 为时钟同步的状态
+type ClockStats struct {
+	Offset   time.Duration // 服务器时间减本地时间，正数表示本地时钟偏慢
+	RTT      time.Duration // 所选样本的往返时间
+	LastSync time.Time     // 最近一次成功同步的本地时间
+	LastErr  error         // 最近一次同步的错误，成功后清空
+}
+
+// ClockSync 轮询 /api/v1/time 估计本地时钟与服务器的偏差，
+// 并为签名提供修正后的时钟。每次同步取往返时间最短的样本，
+// 假设服务器在往返的中点生成时间戳。
+type ClockSync struct {
+	client  *Client
+	samples int
+	local   func() time.Time
+
+	mu    sync.RWMutex
+	stats ClockStats
+}
+
+// NewClockSync 创建时钟同步器，并把修正后的时钟接入 client.Signer。
+// 需要调用 Sync 或 Run 后偏差才会生效，在此之前 Now 等于本地时间。
+func NewClockSync(client *Client) *ClockSync {
+	cs := &ClockSync{
+		client:  client,
+		samples: DefaultClockSyncSamples,
+		local:   time.Now,
+	}
+	if client.Signer != nil {
+		client.Signer.SetClock(cs.Now)
+	}
+	return cs
+}
+
+// Now 返回按服务器时间修正后的当前时间
+func (cs *ClockSync) Now() time.Time {
+	return cs.local().Add(cs.Offset())
+}
+
+// Offset 返回当前估计的时钟偏差，用于监控和告警
+func (cs *ClockSync) Offset() time.Duration {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.stats.Offset
+}
+
+// Stats 返回时钟同步的状态快照
+func (cs *ClockSync) Stats() ClockStats {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.stats
+}
+
+// Sync 立即测量一次时钟偏差，所有样本都失败时保留原来的偏差并返回最后的错误
+func (cs *ClockSync) Sync(ctx context.Context) error {
+	var (
+		best    time.Duration
+		bestRTT time.Duration = -1
+		lastErr error
+	)
+	for i := 0; i < cs.samples; i++ {
+		t0 := cs.local()
+		server, err := cs.client.GetSystemTime(ctx)
+		t1 := cs.local()
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		rtt := t1.Sub(t0)
+		if bestRTT < 0 || rtt < bestRTT {
+			bestRTT = rtt
+			best = server.Sub(t0.Add(rtt / 2))
+		}
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if bestRTT < 0 {
+		cs.stats.LastErr = lastErr
+		return lastErr
+	}
+	cs.stats = ClockStats{
+		Offset:   best,
+		RTT:      bestRTT,
+		LastSync: cs.local(),
+	}
+	return nil
+}
+
+// Run 立即同步一次，之后每隔 interval 同步，直到 ctx 取消。
+// 单次同步失败不会退出，错误记录在 Stats().LastErr 中。
+func (cs *ClockSync) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("backpack: clock sync interval must be positive, got %s", interval)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		cs.Sync(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package backpack_interface
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClockSyncRunInterval(t *testing.T) {
+	cs := NewClockSync(&Client{})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := cs.Run(context.Background(), interval); err == nil {
+			t.Errorf("Run(%s): want error", interval)
+		}
+	}
+}
+
+func TestClockSync(t *testing.T) {
+	base := time.UnixMilli(testTimestamp)
+	ms := time.Millisecond
+	// 每个样本的本地发送时间、往返时间和服务器时间，本地时钟比服务器慢约 10s
+	samples := []struct {
+		t0, rtt, server time.Duration
+	}{
+		{0, 400 * ms, 200*ms + 11*time.Second},
+		{time.Second, 100 * ms, time.Second + 50*ms + 10*time.Second},
+		{2 * time.Second, 300 * ms, 2*time.Second + 150*ms + 12*time.Second},
+	}
+	var (
+		ticks []time.Time
+		calls int
+		fail  bool
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/time" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, base.Add(samples[calls%len(samples)].server).UnixMilli())
+		calls++
+	})
+	cs := NewClockSync(c)
+	cs.local = func() time.Time {
+		now := ticks[0]
+		if len(ticks) > 1 {
+			ticks = ticks[1:]
+		}
+		return now
+	}
+	reset := func() {
+		ticks = nil
+		for _, s := range samples {
+			ticks = append(ticks, base.Add(s.t0), base.Add(s.t0+s.rtt))
+		}
+		ticks = append(ticks, base.Add(3*time.Second))
+	}
+
+	reset()
+	if err := cs.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	// 取往返时间最短的第二个样本
+	stats := cs.Stats()
+	if stats.Offset != 10*time.Second || stats.RTT != 100*ms || !stats.LastSync.Equal(base.Add(3*time.Second)) {
+		t.Fatalf("stats = %+v, want offset 10s rtt 100ms", stats)
+	}
+	// 签名器使用修正后的时钟
+	if got, want := c.Signer.Sign("balanceQuery", nil).Timestamp, base.Add(13*time.Second).UnixMilli(); got != want {
+		t.Errorf("signed timestamp = %d, want %d", got, want)
+	}
+
+	// 所有样本都失败时保留原来的偏差
+	fail = true
+	reset()
+	if err := cs.Sync(context.Background()); err == nil {
+		t.Fatal("Sync: want error")
+	}
+	stats = cs.Stats()
+	if stats.Offset != 10*time.Second || stats.LastErr == nil || !stats.LastSync.Equal(base.Add(3*time.Second)) {
+		t.Fatalf("stats after failure = %+v, want old offset and LastErr", stats)
+	}
+
+	fail = false
+	reset()
+	if err := cs.Sync(context.Background()); err != nil || cs.Stats().LastErr != nil {
+		t.Fatalf("Sync = %v, LastErr = %v; want both cleared", err, cs.Stats().LastErr)
+	}
+}
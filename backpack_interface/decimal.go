@@ -0,0 +1,358 @@
+package backpack_interface
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal 为任意精度的十进制定点数，用于价格和数量。
+// 数值为 coef / 10^scale，零值表示 0。
+// String 保留解析时的小数位数，保证签名串和请求体中的格式一致。
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+var bigTen = big.NewInt(10)
+
+// maxExponent 为科学计数法指数的绝对值上限，避免 "1e900000000" 这样的输入构造巨大的整数
+const maxExponent = 1000
+
+// ParseDecimal 从字符串解析 Decimal，支持 "1.23"、"-0.5"、"1e-8" 等格式
+func ParseDecimal(s string) (Decimal, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("decimal: empty string")
+	}
+
+	exp := int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("decimal: invalid exponent in %q", orig)
+		}
+		if e > maxExponent || e < -maxExponent {
+			return Decimal{}, fmt.Errorf("decimal: exponent out of range in %q", orig)
+		}
+		exp = e
+		s = s[:i]
+	}
+
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("decimal: invalid number %q", orig)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		coef.Neg(coef)
+	}
+	scale := int64(len(fracPart)) - exp
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	if scale > 1<<31-1 {
+		return Decimal{}, fmt.Errorf("decimal: exponent out of range in %q", orig)
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// MustDecimal 与 ParseDecimal 相同，解析失败时 panic，仅用于常量初始化
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimal 返回 unscaled / 10^scale
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{coef: new(big.Int).Mul(big.NewInt(unscaled), pow10(int64(-scale)))}
+	}
+	return Decimal{coef: big.NewInt(unscaled), scale: scale}
+}
+
+// DecimalFromInt 返回整数 i
+func DecimalFromInt(i int64) Decimal {
+	return NewDecimal(i, 0)
+}
+
+// DecimalFromFloat 按 f 的最短十进制表示转换，仅用于兼容旧的 float64 参数
+func DecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(n), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// at 返回 d 在 scale 位小数下的 coef，scale 不能小于 d.scale
+func (d Decimal) at(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), pow10(int64(scale-d.scale)))
+}
+
+// String 返回保留小数位数的十进制表示
+func (d Decimal) String() string {
+	coef := d.int()
+	s := new(big.Int).Abs(coef).String()
+	if d.scale > 0 {
+		if len(s) <= int(d.scale) {
+			s = strings.Repeat("0", int(d.scale)-len(s)+1) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+	if coef.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Float64 返回最接近的 float64 值
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Scale 返回小数位数
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign 返回 -1、0 或 1
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero 判断是否为 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp 比较 d 和 o，返回 -1、0 或 1
+func (d Decimal) Cmp(o Decimal) int {
+	s := maxScale(d, o)
+	return d.at(s).Cmp(o.at(s))
+}
+
+// Equal 判断数值是否相等，忽略小数位数的差异
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+// Neg 返回 -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs 返回 |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Normalize 去掉小数部分末尾的 0，例如 1.500 返回 1.5
+func (d Decimal) Normalize() Decimal {
+	coef := new(big.Int).Set(d.int())
+	scale := d.scale
+	q, r := new(big.Int), new(big.Int)
+	for scale > 0 && coef.Sign() != 0 {
+		q.QuoRem(coef, bigTen, r)
+		if r.Sign() != 0 {
+			break
+		}
+		coef.Set(q)
+		scale--
+	}
+	if coef.Sign() == 0 {
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// Add 返回 d + o
+func (d Decimal) Add(o Decimal) Decimal {
+	s := maxScale(d, o)
+	return Decimal{coef: new(big.Int).Add(d.at(s), o.at(s)), scale: s}
+}
+
+// Sub 返回 d - o
+func (d Decimal) Sub(o Decimal) Decimal {
+	s := maxScale(d, o)
+	return Decimal{coef: new(big.Int).Sub(d.at(s), o.at(s)), scale: s}
+}
+
+// Mul 返回 d * o
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Quo 返回 d / o，四舍五入到 scale 位小数；o 为 0 时返回 0
+func (d Decimal) Quo(o Decimal, scale int32) Decimal {
+	if o.IsZero() {
+		return Decimal{scale: scale}
+	}
+	// d/o = d.coef*10^o.scale / (o.coef*10^d.scale)，再放大 10^scale
+	num := new(big.Int).Mul(d.int(), pow10(int64(o.scale)+int64(scale)))
+	den := new(big.Int).Mul(o.int(), pow10(int64(d.scale)))
+	return Decimal{coef: divRound(num, den, roundHalfUp), scale: scale}
+}
+
+// FloorToStep 向下取整到 step 的整数倍，结果的小数位数与 step 相同
+func (d Decimal) FloorToStep(step Decimal) Decimal {
+	return d.toStep(step, roundFloor)
+}
+
+// CeilToStep 向上取整到 step 的整数倍，结果的小数位数与 step 相同
+func (d Decimal) CeilToStep(step Decimal) Decimal {
+	return d.toStep(step, roundCeil)
+}
+
+// RoundToStep 四舍五入到 step 的整数倍，结果的小数位数与 step 相同
+func (d Decimal) RoundToStep(step Decimal) Decimal {
+	return d.toStep(step, roundHalfUp)
+}
+
+// IsMultipleOf 判断 d 是否为 step 的整数倍；step 为 0 时总是 true
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if step.IsZero() {
+		return true
+	}
+	num := new(big.Int).Mul(d.int(), pow10(int64(step.scale)))
+	den := new(big.Int).Mul(step.int(), pow10(int64(d.scale)))
+	return new(big.Int).Rem(num, den).Sign() == 0
+}
+
+func (d Decimal) toStep(step Decimal, mode roundingMode) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+	num := new(big.Int).Mul(d.int(), pow10(int64(step.scale)))
+	den := new(big.Int).Mul(step.int(), pow10(int64(d.scale)))
+	n := divRound(num, den, mode)
+	return Decimal{coef: n.Mul(n, step.int()), scale: step.scale}
+}
+
+type roundingMode int
+
+const (
+	roundHalfUp roundingMode = iota
+	roundFloor
+	roundCeil
+)
+
+// divRound 按 mode 计算 num / den 的整数结果
+func divRound(num, den *big.Int, mode roundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// 商的真实符号
+	neg := (num.Sign() < 0) != (den.Sign() < 0)
+	switch mode {
+	case roundFloor:
+		if neg {
+			q.Sub(q, big.NewInt(1))
+		}
+	case roundCeil:
+		if !neg {
+			q.Add(q, big.NewInt(1))
+		}
+	default:
+		twice := new(big.Int).Abs(r)
+		twice.Lsh(twice, 1)
+		if twice.Cmp(new(big.Int).Abs(den)) >= 0 {
+			if neg {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+	}
+	return q
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// MarshalJSON 编码为带引号的字符串，与交易所的格式一致
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON 支持字符串和数字，null 和空字符串解码为 0
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+	s := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return fmt.Errorf("decimal: %w", err)
+		}
+		if s == "" {
+			*d = Decimal{}
+			return nil
+		}
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler
+func (d *Decimal) UnmarshalText(b []byte) error {
+	v, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
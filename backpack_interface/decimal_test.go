@@ -0,0 +1,231 @@
+package backpack_interface
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		scale int32
+	}{
+		{"0", "0", 0},
+		{"1.23", "1.23", 2},
+		{"0.10", "0.10", 2},
+		{"-0.5", "-0.5", 1},
+		{"+7", "7", 0},
+		{".5", "0.5", 1},
+		{"5.", "5", 0},
+		{" 12.5 ", "12.5", 1},
+		{"0.00000001", "0.00000001", 8},
+		{"1e-8", "0.00000001", 8},
+		{"1.5E2", "150", 0},
+		{"1e-1000", "0." + strings.Repeat("0", 999) + "1", 1000},
+		{"-2.5e-3", "-0.0025", 4},
+		{"12345678901234567890.123456789", "12345678901234567890.123456789", 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d, err := ParseDecimal(tt.in)
+			if err != nil {
+				t.Fatalf("ParseDecimal: %v", err)
+			}
+			if d.String() != tt.want || d.Scale() != tt.scale {
+				t.Errorf("got %s scale %d, want %s scale %d", d, d.Scale(), tt.want, tt.scale)
+			}
+			// String 的结果可以原样解析回来
+			if back := MustDecimal(d.String()); back.String() != d.String() {
+				t.Errorf("round trip %s -> %s", d, back)
+			}
+		})
+	}
+}
+
+func TestParseDecimalErrors(t *testing.T) {
+	for _, in := range []string{"", " ", ".", "-", "+-1", "--1", "1.2.3", "abc", "1e", "1ex", "0x10", "1,5", "NaN",
+		"1e1001", "1e-1001", "1e900000000", "-1e-900000000"} {
+		if d, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %s, want error", in, d)
+		}
+	}
+}
+
+func TestDecimalZeroValue(t *testing.T) {
+	var d Decimal
+	if d.String() != "0" || !d.IsZero() || d.Sign() != 0 {
+		t.Errorf("zero value = %s", d)
+	}
+	if got := d.Add(MustDecimal("1.5")); got.String() != "1.5" {
+		t.Errorf("0 + 1.5 = %s", got)
+	}
+	if !d.Equal(MustDecimal("0.000")) {
+		t.Error("zero value should equal 0.000")
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustDecimal
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"add", d("1.1").Add(d("2.25")), "3.35"},
+		{"sub", d("1").Sub(d("2.5")), "-1.5"},
+		{"mul", d("1.5").Mul(d("-0.2")), "-0.30"},
+		{"quo", d("10").Quo(d("3"), 4), "3.3333"},
+		{"quo half up", d("2").Quo(d("3"), 2), "0.67"},
+		{"quo negative", d("-2").Quo(d("3"), 2), "-0.67"},
+		{"quo by zero", d("1").Quo(d("0"), 2), "0.00"},
+		{"neg", d("1.20").Neg(), "-1.20"},
+		{"abs", d("-3.5").Abs(), "3.5"},
+		{"normalize", d("1.500").Normalize(), "1.5"},
+		{"normalize integer", d("12.000").Normalize(), "12"},
+		{"normalize zero", d("0.000").Normalize(), "0"},
+		{"normalize negative", d("-0.10").Normalize(), "-0.1"},
+		{"normalize no fraction", d("100").Normalize(), "100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.String() != tt.want {
+				t.Errorf("got %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+
+	if d("1.0").Cmp(d("1")) != 0 || d("-1").Cmp(d("0.5")) >= 0 || d("0.51").Cmp(d("0.5")) <= 0 {
+		t.Error("Cmp ignores scale and orders by value")
+	}
+}
+
+func TestDecimalToStep(t *testing.T) {
+	tests := []struct {
+		value, step        string
+		floor, ceil, round string
+	}{
+		{"1.234", "0.01", "1.23", "1.24", "1.23"},
+		{"1.235", "0.01", "1.23", "1.24", "1.24"},
+		{"1.23", "0.01", "1.23", "1.23", "1.23"},
+		{"-1.234", "0.01", "-1.24", "-1.23", "-1.23"},
+		{"-1.235", "0.01", "-1.24", "-1.23", "-1.24"},
+		{"-1.23", "0.01", "-1.23", "-1.23", "-1.23"},
+		{"7", "5", "5", "10", "5"},
+		{"7.5", "5", "5", "10", "10"},
+		{"-7.5", "5", "-10", "-5", "-10"},
+		{"0.37", "0.25", "0.25", "0.50", "0.25"},
+		{"12", "0.5", "12.0", "12.0", "12.0"},
+		{"0.004", "0.01", "0.00", "0.01", "0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value+"/"+tt.step, func(t *testing.T) {
+			v, step := MustDecimal(tt.value), MustDecimal(tt.step)
+			if got := v.FloorToStep(step).String(); got != tt.floor {
+				t.Errorf("FloorToStep = %s, want %s", got, tt.floor)
+			}
+			if got := v.CeilToStep(step).String(); got != tt.ceil {
+				t.Errorf("CeilToStep = %s, want %s", got, tt.ceil)
+			}
+			if got := v.RoundToStep(step).String(); got != tt.round {
+				t.Errorf("RoundToStep = %s, want %s", got, tt.round)
+			}
+		})
+	}
+
+	// step 不大于 0 时原样返回
+	for _, step := range []string{"0", "-0.01"} {
+		if got := MustDecimal("1.234").FloorToStep(MustDecimal(step)); got.String() != "1.234" {
+			t.Errorf("FloorToStep(%s) = %s, want 1.234", step, got)
+		}
+	}
+}
+
+func TestDecimalIsMultipleOf(t *testing.T) {
+	tests := []struct {
+		value, step string
+		want        bool
+	}{
+		{"1.23", "0.01", true},
+		{"1.230", "0.01", true},
+		{"1.235", "0.01", false},
+		{"-1.23", "0.01", true},
+		{"-1.235", "0.01", false},
+		{"0", "0.01", true},
+		{"10", "2.5", true},
+		{"11", "2.5", false},
+		{"1.5", "0.5", true},
+		{"1.23", "0", true},
+		{"0.3", "0.1", true},
+	}
+	for _, tt := range tests {
+		if got := MustDecimal(tt.value).IsMultipleOf(MustDecimal(tt.step)); got != tt.want {
+			t.Errorf("%s.IsMultipleOf(%s) = %v, want %v", tt.value, tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	b, err := json.Marshal(struct {
+		Price Decimal  `json:"price"`
+		Zero  Decimal  `json:"zero"`
+		Ptr   *Decimal `json:"ptr"`
+	}{Price: MustDecimal("0.10")})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `{"price":"0.10","zero":"0","ptr":null}`; string(b) != want {
+		t.Errorf("Marshal = %s, want %s", b, want)
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"1.50"`, "1.50"},
+		{`1.50`, "1.50"},
+		{`-3`, "-3"},
+		{`"1e-3"`, "0.001"},
+		{`""`, "0"},
+		{`null`, "0"},
+		{` "2" `, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d := MustDecimal("9.99") // null 和空字符串应覆盖原值
+			if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if d.String() != tt.want {
+				t.Errorf("got %s, want %s", d, tt.want)
+			}
+		})
+	}
+
+	for _, in := range []string{`"abc"`, `true`, `"1.2.3"`, `{}`, `1e900000000`} {
+		var d Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s) = %s, want error", in, d)
+		}
+	}
+
+	var m map[string]Decimal
+	if err := json.Unmarshal([]byte(`{"a":"1.1","b":null}`), &m); err != nil || m["a"].String() != "1.1" || !m["b"].IsZero() {
+		t.Errorf("Unmarshal map = %v, %v", m, err)
+	}
+}
+
+func TestDecimalText(t *testing.T) {
+	var d Decimal
+	if err := d.UnmarshalText([]byte("-0.25")); err != nil || d.String() != "-0.25" {
+		t.Fatalf("UnmarshalText = %s, %v", d, err)
+	}
+	b, _ := d.MarshalText()
+	if string(b) != "-0.25" {
+		t.Errorf("MarshalText = %s", b)
+	}
+	if err := d.UnmarshalText([]byte("x")); err == nil {
+		t.Error("UnmarshalText(x): want error")
+	}
+}
@@ -0,0 +1,278 @@
+package backpack_interface
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Side 为订单方向
+type Side string
+
+const (
+	SideBid Side = "Bid" // 买
+	SideAsk Side = "Ask" // 卖
+)
+
+var sides = []Side{SideBid, SideAsk}
+
+// Valid 判断是否为交易所支持的取值
+func (s Side) Valid() bool { return isEnum(s, sides) }
+
+// MarshalJSON 编码前检查取值，空值编码为 ""
+func (s Side) MarshalJSON() ([]byte, error) { return marshalEnum("side", s, sides) }
+
+// UnmarshalJSON 接受未知的取值，可用 Valid 检查
+func (s *Side) UnmarshalJSON(b []byte) error { return unmarshalEnum("side", b, s) }
+
+// Opposite 返回相反的方向
+func (s Side) Opposite() Side {
+	switch s {
+	case SideBid:
+		return SideAsk
+	case SideAsk:
+		return SideBid
+	}
+	return s
+}
+
+// OrderType 为订单类型
+type OrderType string
+
+const (
+	OrderTypeLimit  OrderType = "Limit"
+	OrderTypeMarket OrderType = "Market"
+)
+
+var orderTypes = []OrderType{OrderTypeLimit, OrderTypeMarket}
+
+// Valid 判断是否为交易所支持的取值
+func (t OrderType) Valid() bool { return isEnum(t, orderTypes) }
+
+// MarshalJSON 编码前检查取值，空值编码为 ""
+func (t OrderType) MarshalJSON() ([]byte, error) { return marshalEnum("orderType", t, orderTypes) }
+
+// UnmarshalJSON 接受未知的取值，可用 Valid 检查
+func (t *OrderType) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum("orderType", b, t)
+}
+
+// TimeInForce 为订单的有效方式
+type TimeInForce string
+
+const (
+	TimeInForceGTC TimeInForce = "GTC" // 成交或撤销前一直有效
+	TimeInForceIOC TimeInForce = "IOC" // 立即成交，剩余部分撤销
+	TimeInForceFOK TimeInForce = "FOK" // 全部立即成交，否则撤销
+)
+
+var timeInForces = []TimeInForce{TimeInForceGTC, TimeInForceIOC, TimeInForceFOK}
+
+// Valid 判断是否为交易所支持的取值
+func (t TimeInForce) Valid() bool { return isEnum(t, timeInForces) }
+
+// MarshalJSON 编码前检查取值，空值编码为 ""
+func (t TimeInForce) MarshalJSON() ([]byte, error) {
+	return marshalEnum("timeInForce", t, timeInForces)
+}
+
+// UnmarshalJSON 接受未知的取值，可用 Valid 检查
+func (t *TimeInForce) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum("timeInForce", b, t)
+}
+
+// SelfTradePrevention 为自成交的处理方式
+type SelfTradePrevention string
+
+const (
+	SelfTradeRejectTaker SelfTradePrevention = "RejectTaker"
+	SelfTradeRejectMaker SelfTradePrevention = "RejectMaker"
+	SelfTradeRejectBoth  SelfTradePrevention = "RejectBoth"
+	SelfTradeAllow       SelfTradePrevention = "Allow"
+)
+
+var selfTradePreventions = []SelfTradePrevention{
+	SelfTradeRejectTaker, SelfTradeRejectMaker, SelfTradeRejectBoth, SelfTradeAllow,
+}
+
+// Valid 判断是否为交易所支持的取值
+func (p SelfTradePrevention) Valid() bool { return isEnum(p, selfTradePreventions) }
+
+// MarshalJSON 编码前检查取值，空值编码为 ""
+func (p SelfTradePrevention) MarshalJSON() ([]byte, error) {
+	return marshalEnum("selfTradePrevention", p, selfTradePreventions)
+}
+
+// UnmarshalJSON 接受未知的取值，可用 Valid 检查
+func (p *SelfTradePrevention) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum("selfTradePrevention", b, p)
+}
+
+// KlineInterval 为 K 线周期
+type KlineInterval string
+
+const (
+	KlineInterval1m     KlineInterval = "1m"
+	KlineInterval3m     KlineInterval = "3m"
+	KlineInterval5m     KlineInterval = "5m"
+	KlineInterval15m    KlineInterval = "15m"
+	KlineInterval30m    KlineInterval = "30m"
+	KlineInterval1h     KlineInterval = "1h"
+	KlineInterval2h     KlineInterval = "2h"
+	KlineInterval4h     KlineInterval = "4h"
+	KlineInterval6h     KlineInterval = "6h"
+	KlineInterval8h     KlineInterval = "8h"
+	KlineInterval12h    KlineInterval = "12h"
+	KlineInterval1d     KlineInterval = "1d"
+	KlineInterval3d     KlineInterval = "3d"
+	KlineInterval1w     KlineInterval = "1w"
+	KlineInterval1month KlineInterval = "1month"
+)
+
+// KlineIntervals 为交易所支持的 K 线周期，从短到长
+var KlineIntervals = []KlineInterval{
+	KlineInterval1m, KlineInterval3m, KlineInterval5m, KlineInterval15m, KlineInterval30m,
+	KlineInterval1h, KlineInterval2h, KlineInterval4h, KlineInterval6h, KlineInterval8h, KlineInterval12h,
+	KlineInterval1d, KlineInterval3d, KlineInterval1w, KlineInterval1month,
+}
+
+var klineDurations = map[KlineInterval]time.Duration{
+	KlineInterval1m:  time.Minute,
+	KlineInterval3m:  3 * time.Minute,
+	KlineInterval5m:  5 * time.Minute,
+	KlineInterval15m: 15 * time.Minute,
+	KlineInterval30m: 30 * time.Minute,
+	KlineInterval1h:  time.Hour,
+	KlineInterval2h:  2 * time.Hour,
+	KlineInterval4h:  4 * time.Hour,
+	KlineInterval6h:  6 * time.Hour,
+	KlineInterval8h:  8 * time.Hour,
+	KlineInterval12h: 12 * time.Hour,
+	KlineInterval1d:  24 * time.Hour,
+	KlineInterval3d:  3 * 24 * time.Hour,
+	KlineInterval1w:  7 * 24 * time.Hour,
+}
+
+// Valid 判断是否为交易所支持的取值
+func (i KlineInterval) Valid() bool { return isEnum(i, KlineIntervals) }
+
+// Duration 返回周期的时长，1month 的长度不固定，返回 0
+func (i KlineInterval) Duration() time.Duration { return klineDurations[i] }
+
+// MarshalJSON 编码前检查取值，空值编码为 ""
+func (i KlineInterval) MarshalJSON() ([]byte, error) {
+	return marshalEnum("interval", i, KlineIntervals)
+}
+
+// UnmarshalJSON 接受未知的取值，可用 Valid 检查
+func (i *KlineInterval) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum("interval", b, i)
+}
+
+// 以下状态类型只出现在响应中，没有自定义编解码；可用 Valid 判断是否为已知状态。
+
+// OrderStatus 为订单状态
+type OrderStatus string
+
+const (
+	OrderStatusNew             OrderStatus = "New"
+	OrderStatusPartiallyFilled OrderStatus = "PartiallyFilled"
+	OrderStatusFilled          OrderStatus = "Filled"
+	OrderStatusCancelled       OrderStatus = "Cancelled"
+	OrderStatusExpired         OrderStatus = "Expired"
+	OrderStatusTriggerPending  OrderStatus = "TriggerPending"
+	OrderStatusTriggerFailed   OrderStatus = "TriggerFailed"
+)
+
+var orderStatuses = []OrderStatus{
+	OrderStatusNew, OrderStatusPartiallyFilled, OrderStatusFilled, OrderStatusCancelled,
+	OrderStatusExpired, OrderStatusTriggerPending, OrderStatusTriggerFailed,
+}
+
+// Valid 判断是否为已知的状态
+func (s OrderStatus) Valid() bool { return isEnum(s, orderStatuses) }
+
+// Terminal 判断订单是否已经结束，不会再有成交
+func (s OrderStatus) Terminal() bool {
+	switch s {
+	case OrderStatusFilled, OrderStatusCancelled, OrderStatusExpired, OrderStatusTriggerFailed:
+		return true
+	}
+	return false
+}
+
+// DepositStatus 为存款状态
+type DepositStatus string
+
+const (
+	DepositStatusInitiated       DepositStatus = "initiated"
+	DepositStatusOrdered         DepositStatus = "ordered"
+	DepositStatusConfirmed       DepositStatus = "confirmed"
+	DepositStatusCancelled       DepositStatus = "cancelled"
+	DepositStatusDeclined        DepositStatus = "declined"
+	DepositStatusExpired         DepositStatus = "expired"
+	DepositStatusRefundInitiated DepositStatus = "refundInitiated"
+	DepositStatusRefunded        DepositStatus = "refunded"
+)
+
+var depositStatuses = []DepositStatus{
+	DepositStatusInitiated, DepositStatusOrdered, DepositStatusConfirmed, DepositStatusCancelled,
+	DepositStatusDeclined, DepositStatusExpired, DepositStatusRefundInitiated, DepositStatusRefunded,
+}
+
+// Valid 判断是否为已知的状态
+func (s DepositStatus) Valid() bool { return isEnum(s, depositStatuses) }
+
+// WithdrawalStatus 为提款状态
+type WithdrawalStatus string
+
+const (
+	WithdrawalStatusPending                       WithdrawalStatus = "pending"
+	WithdrawalStatusConfirmed                     WithdrawalStatus = "confirmed"
+	WithdrawalStatusVerifying                     WithdrawalStatus = "verifying"
+	WithdrawalStatusVoid                          WithdrawalStatus = "void"
+	WithdrawalStatusOwnershipVerificationRequired WithdrawalStatus = "ownershipVerificationRequired"
+	WithdrawalStatusRecipientInformationRequired  WithdrawalStatus = "recipientInformationRequired"
+	WithdrawalStatusRecipientInformationProvided  WithdrawalStatus = "recipientInformationProvided"
+)
+
+var withdrawalStatuses = []WithdrawalStatus{
+	WithdrawalStatusPending, WithdrawalStatusConfirmed, WithdrawalStatusVerifying, WithdrawalStatusVoid,
+	WithdrawalStatusOwnershipVerificationRequired, WithdrawalStatusRecipientInformationRequired,
+	WithdrawalStatusRecipientInformationProvided,
+}
+
+// Valid 判断是否为已知的状态
+func (s WithdrawalStatus) Valid() bool { return isEnum(s, withdrawalStatuses) }
+
+func isEnum[T ~string](v T, values []T) bool {
+	for _, x := range values {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalEnum 编码枚举值，非空的未知取值返回错误
+func marshalEnum[T ~string](name string, v T, values []T) ([]byte, error) {
+	if v != "" && !isEnum(v, values) {
+		return nil, fmt.Errorf("backpack: invalid %s %q, want one of %v", name, string(v), values)
+	}
+	return json.Marshal(string(v))
+}
+
+// unmarshalEnum 解码枚举值，null 解码为空值。
+// 未知的取值原样保留，避免交易所新增取值时整个响应解码失败。
+func unmarshalEnum[T ~string](name string, b []byte, v *T) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("backpack: decode %s: %w", name, err)
+	}
+	if s == nil {
+		*v = ""
+		return nil
+	}
+	*v = T(*s)
+	return nil
+}
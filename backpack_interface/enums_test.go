@@ -0,0 +1,109 @@
+package backpack_interface
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestEnumUnmarshalAcceptsUnknown(t *testing.T) {
+	body := `[{"id":"1","orderType":"StopLimit","side":"Bid","timeInForce":"GTD",` +
+		`"selfTradePrevention":null,"status":"Triggered"},{"id":"2","orderType":"Limit","side":"Ask"}]`
+	var orders []Order
+	if err := json.Unmarshal([]byte(body), &orders); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("decoded %d orders, want 2", len(orders))
+	}
+	o := orders[0]
+	if o.OrderType != "StopLimit" || o.OrderType.Valid() {
+		t.Errorf("OrderType = %q valid %v, want unknown StopLimit", o.OrderType, o.OrderType.Valid())
+	}
+	if o.TimeInForce != "GTD" || o.SelfTradePrevention != "" || o.Status.Valid() {
+		t.Errorf("got %q %q %q", o.TimeInForce, o.SelfTradePrevention, o.Status)
+	}
+	if orders[1].OrderType != OrderTypeLimit || orders[1].Side != SideAsk {
+		t.Errorf("second order = %q %q, want Limit Ask", orders[1].OrderType, orders[1].Side)
+	}
+
+	var ev struct {
+		Side Side `json:"side"`
+	}
+	if err := json.Unmarshal([]byte(`{"side":1}`), &ev); err == nil {
+		t.Error("Unmarshal non-string side: want error")
+	}
+}
+
+func TestEnumMarshalRejectsUnknown(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"side", SideBid, `"Bid"`},
+		{"empty side", Side(""), `""`},
+		{"order type", OrderTypeMarket, `"Market"`},
+		{"time in force", TimeInForceIOC, `"IOC"`},
+		{"self trade", SelfTradeRejectBoth, `"RejectBoth"`},
+		{"interval", KlineInterval1month, `"1month"`},
+		{"bad side", Side("buy"), ""},
+		{"bad order type", OrderType("limit"), ""},
+		{"bad time in force", TimeInForce("GTD"), ""},
+		{"bad self trade", SelfTradePrevention("Reject"), ""},
+		{"bad interval", KlineInterval("2m"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.value)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Marshal = %s, want error", b)
+				}
+				return
+			}
+			if err != nil || string(b) != tt.want {
+				t.Fatalf("Marshal = %s, %v; want %s", b, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsUnknownEnums(t *testing.T) {
+	valid := LimitOrder("SOL_USDC", SideBid, MustDecimal("10"), MustDecimal("1"))
+	tests := []struct {
+		name   string
+		modify func(*CreateOrder)
+	}{
+		{"side", func(co *CreateOrder) { co.Side = "buy" }},
+		{"order type", func(co *CreateOrder) { co.OrderType = "StopLimit" }},
+		{"time in force", func(co *CreateOrder) { co.TimeInForce = "GTD" }},
+		{"self trade", func(co *CreateOrder) { co.SelfTradePrevention = "Reject" }},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			co := valid
+			tt.modify(&co)
+			if err := co.Validate(); !errors.Is(err, ErrInvalidOrder) {
+				t.Fatalf("Validate = %v, want ErrInvalidOrder", err)
+			}
+		})
+	}
+}
+
+func TestKlineIntervalDuration(t *testing.T) {
+	for _, iv := range KlineIntervals {
+		if !iv.Valid() {
+			t.Errorf("%s not valid", iv)
+		}
+		if iv != KlineInterval1month && iv.Duration() <= 0 {
+			t.Errorf("%s has no duration", iv)
+		}
+	}
+	if KlineInterval1month.Duration() != 0 {
+		t.Error("1month should have no fixed duration")
+	}
+}
@@ -0,0 +1,48 @@
+package backpack_interface
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrMissingCredentials 表示在没有 Signer 的客户端上调用了需要签名的接口
+var ErrMissingCredentials = errors.New("backpack: signed request requires api key and secret")
+
+// APIError 为交易所返回的业务错误
+type APIError struct {
+	StatusCode int           // HTTP 状态码
+	Code       string        // 交易所错误码，例如 INVALID_CLIENT_REQUEST
+	Message    string        // 错误信息
+	Path       string        // 请求路径
+	RetryAfter time.Duration // 429 响应的 Retry-After，其他响应为 0
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("backpack: %s: http %d: %s", e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("backpack: %s: http %d: %s: %s", e.Path, e.StatusCode, e.Code, e.Message)
+}
+
+// newAPIError 从错误响应体解析 APIError，响应体不是 JSON 时保留原文
+func newAPIError(statusCode int, path string, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, Path: path}
+	var payload struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && (payload.Code != "" || payload.Message != "") {
+		e.Code = payload.Code
+		e.Message = payload.Message
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(statusCode)
+	}
+	return e
+}
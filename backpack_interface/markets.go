@@ -0,0 +1,236 @@
+package backpack_interface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMarketsTTL 为市场信息缓存的默认有效期
+const DefaultMarketsTTL = 10 * time.Minute
+
+// 后台刷新的参数
+const (
+	marketsRefreshTimeout = 30 * time.Second // 单次刷新的超时
+	marketsRetryDelay     = 30 * time.Second // 刷新失败后再次尝试的间隔
+)
+
+// ErrUnknownMarket 表示交易所的市场列表中没有该交易对
+var ErrUnknownMarket = errors.New("backpack: unknown market")
+
+// MarketRegistry 缓存 getMarkets 返回的市场信息，用于在下单前按价格和数量限制校验订单。
+// 第一次查询时加载市场列表，并发的查询共用同一次请求。缓存过期后继续返回旧数据，
+// 同时在后台刷新；刷新失败时保留旧数据，间隔一段时间后再试，错误可由 LastErr 查看。
+type MarketRegistry struct {
+	client *Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	markets    map[string]Market
+	loaded     time.Time
+	loading    *marketsCall // 首次加载进行中时非空
+	refreshing bool         // 后台刷新进行中
+	attempted  time.Time    // 最近一次后台刷新开始的时间
+	lastErr    error
+}
+
+// marketsCall 为进行中的首次加载，结束后关闭 done
+type marketsCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewMarketRegistry 创建市场信息缓存，ttl 不大于 0 时使用 DefaultMarketsTTL
+func NewMarketRegistry(client *Client, ttl time.Duration) *MarketRegistry {
+	if ttl <= 0 {
+		ttl = DefaultMarketsTTL
+	}
+	return &MarketRegistry{
+		client: client,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Refresh 立即重新读取市场列表，失败时保留原来的数据
+func (r *MarketRegistry) Refresh(ctx context.Context) error {
+	markets, err := r.client.GetMarkets(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finish(markets, err)
+	return err
+}
+
+// LastErr 返回最近一次读取市场列表的错误，成功后清空
+func (r *MarketRegistry) LastErr() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastErr
+}
+
+// finish 保存一次读取的结果，必须持有锁
+func (r *MarketRegistry) finish(markets []Market, err error) {
+	r.lastErr = err
+	if err != nil {
+		return
+	}
+	r.markets = make(map[string]Market, len(markets))
+	for _, m := range markets {
+		r.markets[m.Symbol] = m
+	}
+	r.loaded = r.now()
+}
+
+// snapshot 返回缓存的市场；缓存为空时加载，过期时在后台刷新。
+// 返回的 map 不会再被修改，可以在锁外读取。
+func (r *MarketRegistry) snapshot(ctx context.Context) (map[string]Market, error) {
+	r.mu.Lock()
+	if r.markets != nil {
+		markets := r.markets
+		now := r.now()
+		if now.Sub(r.loaded) >= r.ttl && !r.refreshing && now.Sub(r.attempted) >= marketsRetryDelay {
+			r.refreshing = true
+			r.attempted = now
+			go r.refreshBackground()
+		}
+		r.mu.Unlock()
+		return markets, nil
+	}
+
+	if call := r.loading; call != nil {
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.markets, nil
+	}
+
+	call := &marketsCall{done: make(chan struct{})}
+	r.loading = call
+	r.mu.Unlock()
+
+	markets, err := r.client.GetMarkets(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finish(markets, err)
+	r.loading = nil
+	call.err = err
+	close(call.done)
+	if err != nil {
+		return nil, err
+	}
+	return r.markets, nil
+}
+
+func (r *MarketRegistry) refreshBackground() {
+	ctx, cancel := context.WithTimeout(context.Background(), marketsRefreshTimeout)
+	defer cancel()
+	markets, err := r.client.GetMarkets(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshing = false
+	r.finish(markets, err)
+}
+
+// Market 返回 symbol 的市场信息
+func (r *MarketRegistry) Market(ctx context.Context, symbol string) (Market, error) {
+	markets, err := r.snapshot(ctx)
+	if err != nil {
+		return Market{}, err
+	}
+	m, ok := markets[symbol]
+	if !ok {
+		return Market{}, fmt.Errorf("%w: %q", ErrUnknownMarket, symbol)
+	}
+	return m, nil
+}
+
+// Markets 返回缓存的所有市场，按交易对排序
+func (r *MarketRegistry) Markets(ctx context.Context) ([]Market, error) {
+	markets, err := r.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rst := make([]Market, 0, len(markets))
+	for _, m := range markets {
+		rst = append(rst, m)
+	}
+	sort.Slice(rst, func(i, j int) bool { return rst[i].Symbol < rst[j].Symbol })
+	return rst, nil
+}
+
+// ValidateOrder 先调用 co.Validate，再按 co.Symbol 的市场限制校验。
+// 校验失败的错误包装 ErrInvalidOrder，未知交易对还包装 ErrUnknownMarket；
+// 读取市场列表失败时返回原始错误。
+func (r *MarketRegistry) ValidateOrder(ctx context.Context, co CreateOrder) error {
+	if err := co.Validate(); err != nil {
+		return err
+	}
+	m, err := r.Market(ctx, co.Symbol)
+	if errors.Is(err, ErrUnknownMarket) {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+	if err != nil {
+		return err
+	}
+	return m.ValidateOrder(co)
+}
+
+// ValidateOrder 按市场的价格和数量限制校验订单：
+// price 和 triggerPrice 在 [minPrice, maxPrice] 内且为 tickSize 的整数倍，
+// quantity 在 [minQuantity, maxQuantity] 内且为 stepSize 的整数倍。
+// 限制为 0 时不检查该项。不检查 co.Validate 已经检查的字段。
+func (m Market) ValidateOrder(co CreateOrder) error {
+	if co.Symbol != m.Symbol {
+		return fmt.Errorf("%w: order symbol %q does not match market %q", ErrInvalidOrder, co.Symbol, m.Symbol)
+	}
+	pf, qf := m.Filters.Price, m.Filters.Quantity
+	for _, f := range []struct {
+		name  string
+		value Decimal
+	}{
+		{"price", co.Price},
+		{"triggerPrice", co.TriggerPrice},
+	} {
+		if err := m.checkFilter(f.name, f.value, pf.MinPrice, pf.MaxPrice, pf.TickSize, "tickSize", m.RoundPrice); err != nil {
+			return err
+		}
+	}
+	return m.checkFilter("quantity", co.Quantity, qf.MinQuantity, qf.MaxQuantity, qf.StepSize, "stepSize", m.RoundQuantity)
+}
+
+// checkFilter 检查一个非零字段的上下限和步长，round 用于在错误中给出取整后的值
+func (m Market) checkFilter(
+	name string,
+	value, min, max, step Decimal,
+	stepName string,
+	round func(Decimal) Decimal,
+) error {
+	if value.IsZero() {
+		return nil
+	}
+	if !min.IsZero() && value.Cmp(min) < 0 {
+		return fmt.Errorf("%w: %s %s is below the minimum %s for %s", ErrInvalidOrder, name, value, min, m.Symbol)
+	}
+	if !max.IsZero() && value.Cmp(max) > 0 {
+		return fmt.Errorf("%w: %s %s is above the maximum %s for %s", ErrInvalidOrder, name, value, max, m.Symbol)
+	}
+	if !value.IsMultipleOf(step) {
+		return fmt.Errorf("%w: %s %s is not a multiple of %s %s for %s (rounded: %s)",
+			ErrInvalidOrder, name, value, stepName, step, m.Symbol, round(value))
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package backpack_interface
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testMarkets = `[{"symbol":"SOL_USDC","baseSymbol":"SOL","quoteSymbol":"USDC","filters":{` +
+	`"price":{"minPrice":"0.01","maxPrice":"1000","tickSize":"0.01"},` +
+	`"quantity":{"minQuantity":"0.1","maxQuantity":"500","stepSize":"0.1"}}}]`
+
+func testMarket() Market {
+	return Market{
+		Symbol:      "SOL_USDC",
+		BaseSymbol:  "SOL",
+		QuoteSymbol: "USDC",
+		Filters: MarketFilters{
+			Price:    PriceFilter{MinPrice: MustDecimal("0.01"), MaxPrice: MustDecimal("1000"), TickSize: MustDecimal("0.01")},
+			Quantity: QuantityFilter{MinQuantity: MustDecimal("0.1"), MaxQuantity: MustDecimal("500"), StepSize: MustDecimal("0.1")},
+		},
+	}
+}
+
+func TestMarketValidateOrder(t *testing.T) {
+	d := MustDecimal
+	tests := []struct {
+		name    string
+		order   CreateOrder
+		wantErr string // 为空时应通过校验
+	}{
+		{"valid limit", LimitOrder("SOL_USDC", SideBid, d("10.25"), d("1.5")), ""},
+		{"bounds inclusive", LimitOrder("SOL_USDC", SideBid, d("1000"), d("0.1")), ""},
+		{"market quote ignores quantity filter", MarketOrderQuote("SOL_USDC", SideBid, d("0.001")), ""},
+		{"price below min", LimitOrder("SOL_USDC", SideBid, d("0.001"), d("1")), "price 0.001 is below the minimum 0.01"},
+		{"price above max", LimitOrder("SOL_USDC", SideBid, d("1000.01"), d("1")), "price 1000.01 is above the maximum 1000"},
+		{"price off tick", LimitOrder("SOL_USDC", SideBid, d("10.123"), d("1")),
+			"price 10.123 is not a multiple of tickSize 0.01 for SOL_USDC (rounded: 10.12)"},
+		{"trigger off tick", MarketOrder("SOL_USDC", SideAsk, d("1"), TriggerAt(d("9.999"))),
+			"triggerPrice 9.999 is not a multiple of tickSize 0.01"},
+		{"quantity below min", LimitOrder("SOL_USDC", SideBid, d("10"), d("0.05")), "quantity 0.05 is below the minimum 0.1"},
+		{"quantity above max", MarketOrder("SOL_USDC", SideBid, d("500.1")), "quantity 500.1 is above the maximum 500"},
+		{"quantity off step", LimitOrder("SOL_USDC", SideBid, d("10"), d("1.25")),
+			"quantity 1.25 is not a multiple of stepSize 0.1 for SOL_USDC (rounded: 1.2)"},
+		{"symbol mismatch", LimitOrder("BTC_USDC", SideBid, d("10"), d("1")), "does not match market"},
+	}
+	m := testMarket()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.ValidateOrder(tt.order)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateOrder: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidOrder) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateOrder = %v, want ErrInvalidOrder containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarketValidateOrderZeroFilters(t *testing.T) {
+	m := Market{Symbol: "SOL_USDC"}
+	if err := m.ValidateOrder(LimitOrder("SOL_USDC", SideBid, MustDecimal("123.456789"), MustDecimal("0.0001"))); err != nil {
+		t.Fatalf("ValidateOrder without filters: %v", err)
+	}
+}
+
+func TestMarketRegistryValidateOrder(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(testMarkets))
+	})
+	r := NewMarketRegistry(c, time.Minute)
+	ctx := context.Background()
+
+	if err := r.ValidateOrder(ctx, LimitOrder("SOL_USDC", SideBid, MustDecimal("10"), MustDecimal("1"))); err != nil {
+		t.Fatalf("ValidateOrder: %v", err)
+	}
+	err := r.ValidateOrder(ctx, LimitOrder("FOO_USDC", SideBid, MustDecimal("10"), MustDecimal("1")))
+	if !errors.Is(err, ErrInvalidOrder) || !errors.Is(err, ErrUnknownMarket) {
+		t.Fatalf("ValidateOrder unknown market = %v, want ErrInvalidOrder and ErrUnknownMarket", err)
+	}
+	if _, err := r.Market(ctx, "FOO_USDC"); !errors.Is(err, ErrUnknownMarket) || errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("Market unknown = %v, want only ErrUnknownMarket", err)
+	}
+	err = r.ValidateOrder(ctx, LimitOrder("SOL_USDC", "buy", MustDecimal("10"), MustDecimal("1")))
+	if !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("ValidateOrder bad side = %v, want ErrInvalidOrder", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("GetMarkets called %d times, want 1", n)
+	}
+}
+
+func TestMarketRegistryConcurrentLoad(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(testMarkets))
+	})
+	r := NewMarketRegistry(c, time.Minute)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Market(context.Background(), "SOL_USDC")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Market: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("GetMarkets called %d times, want 1", n)
+	}
+}
+
+func TestMarketRegistryInitialLoadError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r := NewMarketRegistry(c, time.Minute)
+	_, err := r.Market(context.Background(), "SOL_USDC")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("Market = %v, want APIError", err)
+	}
+	if r.LastErr() == nil {
+		t.Fatal("LastErr = nil after failed load")
+	}
+}
+
+func TestMarketRegistryStaleFallback(t *testing.T) {
+	var (
+		failing  atomic.Bool
+		requests = make(chan struct{}, 10)
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		defer func() { requests <- struct{}{} }()
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(testMarkets))
+	})
+	r := NewMarketRegistry(c, time.Minute)
+	now := time.Unix(1700000000, 0)
+	var mu sync.Mutex
+	r.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+	waitRequest := func() {
+		t.Helper()
+		select {
+		case <-requests:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no GetMarkets request")
+		}
+	}
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("condition not met")
+			}
+		}
+	}
+	ctx := context.Background()
+	if _, err := r.Market(ctx, "SOL_USDC"); err != nil {
+		t.Fatalf("Market: %v", err)
+	}
+	waitRequest()
+
+	// 过期后刷新失败，继续使用旧数据
+	failing.Store(true)
+	advance(2 * time.Minute)
+	if _, err := r.Market(ctx, "SOL_USDC"); err != nil {
+		t.Fatalf("Market with stale cache: %v", err)
+	}
+	waitRequest()
+	waitFor(func() bool { return r.LastErr() != nil })
+	if _, err := r.Market(ctx, "SOL_USDC"); err != nil {
+		t.Fatalf("Market after failed refresh: %v", err)
+	}
+	select {
+	case <-requests:
+		t.Fatal("refresh retried before retry delay")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// 间隔过后再次刷新，成功后清空错误
+	failing.Store(false)
+	advance(marketsRetryDelay)
+	if _, err := r.Market(ctx, "SOL_USDC"); err != nil {
+		t.Fatalf("Market: %v", err)
+	}
+	waitRequest()
+	waitFor(func() bool { return r.LastErr() == nil })
+}
@@ -0,0 +1,226 @@
+package backpack_interface
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Token 为资产在某条链上的充提配置
+type Token struct {
+	Blockchain        string  `json:"blockchain"`
+	DepositEnabled    bool    `json:"depositEnabled"`
+	MinimumDeposit    Decimal `json:"minimumDeposit"`
+	WithdrawEnabled   bool    `json:"withdrawEnabled"`
+	MinimumWithdrawal Decimal `json:"minimumWithdrawal"`
+	MaximumWithdrawal Decimal `json:"maximumWithdrawal"`
+	WithdrawalFee     Decimal `json:"withdrawalFee"`
+}
+
+// Asset 为交易所支持的资产
+type Asset struct {
+	Symbol string  `json:"symbol"`
+	Tokens []Token `json:"tokens"`
+}
+
+// PriceFilter 为市场的价格限制
+type PriceFilter struct {
+	MinPrice Decimal `json:"minPrice"`
+	MaxPrice Decimal `json:"maxPrice"`
+	TickSize Decimal `json:"tickSize"`
+}
+
+// QuantityFilter 为市场的数量限制
+type QuantityFilter struct {
+	MinQuantity Decimal `json:"minQuantity"`
+	MaxQuantity Decimal `json:"maxQuantity"`
+	StepSize    Decimal `json:"stepSize"`
+}
+
+// LeverageFilter 为市场的杠杆限制
+type LeverageFilter struct {
+	MinLeverage Decimal `json:"minLeverage"`
+	MaxLeverage Decimal `json:"maxLeverage"`
+	StepSize    Decimal `json:"stepSize"`
+}
+
+// MarketFilters 为市场的下单限制
+type MarketFilters struct {
+	Price    PriceFilter     `json:"price"`
+	Quantity QuantityFilter  `json:"quantity"`
+	Leverage *LeverageFilter `json:"leverage"`
+}
+
+// Market 为交易所支持的市场
+type Market struct {
+	Symbol      string        `json:"symbol"`
+	BaseSymbol  string        `json:"baseSymbol"`
+	QuoteSymbol string        `json:"quoteSymbol"`
+	Filters     MarketFilters `json:"filters"`
+}
+
+// RoundPrice 将价格四舍五入到 tickSize 的整数倍
+func (m Market) RoundPrice(price Decimal) Decimal {
+	return price.RoundToStep(m.Filters.Price.TickSize)
+}
+
+// RoundQuantity 将数量向下取整到 stepSize 的整数倍，避免超出可用余额
+func (m Market) RoundQuantity(quantity Decimal) Decimal {
+	return quantity.FloorToStep(m.Filters.Quantity.StepSize)
+}
+
+// Ticker 为市场过去24小时的汇总统计
+type Ticker struct {
+	Symbol             string  `json:"symbol"`
+	FirstPrice         Decimal `json:"firstPrice"`
+	LastPrice          Decimal `json:"lastPrice"`
+	PriceChange        Decimal `json:"priceChange"`
+	PriceChangePercent Decimal `json:"priceChangePercent"`
+	High               Decimal `json:"high"`
+	Low                Decimal `json:"low"`
+	Volume             Decimal `json:"volume"`
+	QuoteVolume        Decimal `json:"quoteVolume"`
+	Trades             string  `json:"trades"`
+}
+
+// PriceLevel 为订单簿中的一档，JSON 中表示为 [price, quantity]
+type PriceLevel struct {
+	Price    Decimal
+	Quantity Decimal
+}
+
+// UnmarshalJSON 从 [price, quantity] 数组解码
+func (l *PriceLevel) UnmarshalJSON(b []byte) error {
+	var pair []Decimal
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("price level: expected 2 elements, got %d", len(pair))
+	}
+	l.Price, l.Quantity = pair[0], pair[1]
+	return nil
+}
+
+// MarshalJSON 编码为 [price, quantity] 数组
+func (l PriceLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]Decimal{l.Price, l.Quantity})
+}
+
+// Depth 为订单簿深度
+type Depth struct {
+	Asks         []PriceLevel `json:"asks"`
+	Bids         []PriceLevel `json:"bids"`
+	LastUpdateID string       `json:"lastUpdateId"`
+}
+
+// Kline 为一根k线
+type Kline struct {
+	Start  string  `json:"start"`
+	End    string  `json:"end"`
+	Open   Decimal `json:"open"`
+	High   Decimal `json:"high"`
+	Low    Decimal `json:"low"`
+	Close  Decimal `json:"close"`
+	Volume Decimal `json:"volume"`
+	Trades string  `json:"trades"`
+}
+
+// Trade 为一笔公开成交
+type Trade struct {
+	ID            int64   `json:"id"`
+	Price         Decimal `json:"price"`
+	Quantity      Decimal `json:"quantity"`
+	QuoteQuantity Decimal `json:"quoteQuantity"`
+	Timestamp     int64   `json:"timestamp"`
+	IsBuyerMaker  bool    `json:"isBuyerMaker"`
+}
+
+// Status 为系统状态
+type Status struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Balance 为某个资产的账户余额
+type Balance struct {
+	Available Decimal `json:"available"`
+	Locked    Decimal `json:"locked"`
+	Staked    Decimal `json:"staked"`
+}
+
+// Total 返回可用、冻结和质押的总额
+func (b Balance) Total() Decimal {
+	return b.Available.Add(b.Locked).Add(b.Staked)
+}
+
+// Deposit 为一条存款记录
+type Deposit struct {
+	ID                      int64         `json:"id"`
+	ToAddress               string        `json:"toAddress"`
+	FromAddress             string        `json:"fromAddress"`
+	ConfirmationBlockNumber int64         `json:"confirmationBlockNumber"`
+	Identifier              string        `json:"identifier"`
+	ProviderID              string        `json:"providerId"`
+	Source                  string        `json:"source"`
+	Status                  DepositStatus `json:"status"`
+	TransactionHash         string        `json:"transactionHash"`
+	SubaccountID            int64         `json:"subaccountId"`
+	Symbol                  string        `json:"symbol"`
+	Quantity                Decimal       `json:"quantity"`
+	CreatedAt               string        `json:"createdAt"`
+}
+
+// DepositAddress 为存款地址
+type DepositAddress struct {
+	Address string `json:"address"`
+}
+
+// Withdrawal 为一条提款记录
+type Withdrawal struct {
+	ID              int64            `json:"id"`
+	Blockchain      string           `json:"blockchain"`
+	ClientID        string           `json:"clientId"`
+	Identifier      string           `json:"identifier"`
+	Quantity        Decimal          `json:"quantity"`
+	Fee             Decimal          `json:"fee"`
+	Symbol          string           `json:"symbol"`
+	Status          WithdrawalStatus `json:"status"`
+	SubaccountID    int64            `json:"subaccountId"`
+	ToAddress       string           `json:"toAddress"`
+	TransactionHash string           `json:"transactionHash"`
+	CreatedAt       string           `json:"createdAt"`
+}
+
+// Order 为订单信息，下单、查单、撤单和订单历史共用
+type Order struct {
+	ID                    string              `json:"id"`
+	ClientID              uint32              `json:"clientId"`
+	OrderType             OrderType           `json:"orderType"`
+	Symbol                string              `json:"symbol"`
+	Side                  Side                `json:"side"`
+	Price                 Decimal             `json:"price"`
+	TriggerPrice          Decimal             `json:"triggerPrice"`
+	Quantity              Decimal             `json:"quantity"`
+	ExecutedQuantity      Decimal             `json:"executedQuantity"`
+	QuoteQuantity         Decimal             `json:"quoteQuantity"`
+	ExecutedQuoteQuantity Decimal             `json:"executedQuoteQuantity"`
+	TimeInForce           TimeInForce         `json:"timeInForce"`
+	SelfTradePrevention   SelfTradePrevention `json:"selfTradePrevention"`
+	PostOnly              bool                `json:"postOnly"`
+	Status                OrderStatus         `json:"status"`
+	CreatedAt             int64               `json:"createdAt"`
+}
+
+// Fill 为一条成交记录
+type Fill struct {
+	TradeID   int64   `json:"tradeId"`
+	OrderID   string  `json:"orderId"`
+	Symbol    string  `json:"symbol"`
+	Side      Side    `json:"side"`
+	Price     Decimal `json:"price"`
+	Quantity  Decimal `json:"quantity"`
+	Fee       Decimal `json:"fee"`
+	FeeSymbol string  `json:"feeSymbol"`
+	IsMaker   bool    `json:"isMaker"`
+	Timestamp string  `json:"timestamp"`
+}
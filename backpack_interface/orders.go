@@ -0,0 +1,267 @@
+package backpack_interface
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrInvalidOrder 为本地校验订单失败时返回错误的根错误，可用 errors.Is 判断
+var ErrInvalidOrder = errors.New("backpack: invalid order")
+
+// Validate 在发送前检查订单的必填字段和取值
+func (co CreateOrder) Validate() error {
+	if co.Symbol == "" {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidOrder)
+	}
+	if !co.Side.Valid() {
+		return fmt.Errorf("%w: side must be Bid or Ask, got %q", ErrInvalidOrder, co.Side)
+	}
+	for _, f := range []struct {
+		name  string
+		value Decimal
+	}{
+		{"price", co.Price},
+		{"quantity", co.Quantity},
+		{"quoteQuantity", co.QuoteQuantity},
+		{"triggerPrice", co.TriggerPrice},
+	} {
+		if f.value.Sign() < 0 {
+			return fmt.Errorf("%w: %s must not be negative, got %s", ErrInvalidOrder, f.name, f.value)
+		}
+	}
+
+	switch co.OrderType {
+	case OrderTypeLimit:
+		if co.Price.IsZero() {
+			return fmt.Errorf("%w: limit order requires price", ErrInvalidOrder)
+		}
+		if co.Quantity.IsZero() {
+			return fmt.Errorf("%w: limit order requires quantity", ErrInvalidOrder)
+		}
+		if !co.QuoteQuantity.IsZero() {
+			return fmt.Errorf("%w: limit order does not accept quoteQuantity", ErrInvalidOrder)
+		}
+	case OrderTypeMarket:
+		if co.Quantity.IsZero() == co.QuoteQuantity.IsZero() {
+			return fmt.Errorf("%w: market order requires exactly one of quantity and quoteQuantity", ErrInvalidOrder)
+		}
+	default:
+		return fmt.Errorf("%w: orderType must be Limit or Market, got %q", ErrInvalidOrder, co.OrderType)
+	}
+
+	if co.TimeInForce != "" && !co.TimeInForce.Valid() {
+		return fmt.Errorf("%w: timeInForce must be GTC, IOC or FOK, got %q", ErrInvalidOrder, co.TimeInForce)
+	}
+	if co.SelfTradePrevention != "" && !co.SelfTradePrevention.Valid() {
+		return fmt.Errorf("%w: selfTradePrevention must be RejectTaker, RejectMaker, RejectBoth or Allow, got %q",
+			ErrInvalidOrder, co.SelfTradePrevention)
+	}
+	if co.PostOnly {
+		// postOnly 订单只能挂单，不能立即成交
+		if co.OrderType == OrderTypeMarket {
+			return fmt.Errorf("%w: market order cannot be postOnly", ErrInvalidOrder)
+		}
+		if co.TimeInForce == TimeInForceIOC || co.TimeInForce == TimeInForceFOK {
+			return fmt.Errorf("%w: postOnly conflicts with timeInForce %s", ErrInvalidOrder, co.TimeInForce)
+		}
+	}
+	return nil
+}
+
+// validateOrder 在本地校验订单，Client.Markets 非空时还按市场限制校验
+func (c *Client) validateOrder(ctx context.Context, co CreateOrder) error {
+	if c.Markets == nil {
+		return co.Validate()
+	}
+	return c.Markets.ValidateOrder(ctx, co)
+}
+
+// params 返回请求体和签名共用的参数，未设置的可选字段不发送
+func (co CreateOrder) params() map[string]interface{} {
+	params := map[string]interface{}{
+		"orderType": co.OrderType,
+		"side":      co.Side,
+		"symbol":    co.Symbol,
+	}
+	if co.ClientId != 0 {
+		params["clientId"] = co.ClientId
+	}
+	if co.PostOnly {
+		params["postOnly"] = true
+	}
+	if co.SelfTradePrevention != "" {
+		params["selfTradePrevention"] = co.SelfTradePrevention
+	}
+	if co.TimeInForce != "" {
+		params["timeInForce"] = co.TimeInForce
+	}
+	for name, value := range map[string]Decimal{
+		"price":         co.Price,
+		"quantity":      co.Quantity,
+		"quoteQuantity": co.QuoteQuantity,
+		"triggerPrice":  co.TriggerPrice,
+	} {
+		if !value.IsZero() {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// OrderOption 设置 CreateOrder 的可选字段
+type OrderOption func(*CreateOrder)
+
+// LimitOrder 创建限价单
+func LimitOrder(symbol string, side Side, price, quantity Decimal, opts ...OrderOption) CreateOrder {
+	return newOrder(CreateOrder{
+		Symbol:    symbol,
+		Side:      side,
+		OrderType: OrderTypeLimit,
+		Price:     price,
+		Quantity:  quantity,
+	}, opts)
+}
+
+// MarketOrder 创建按基础资产数量成交的市价单
+func MarketOrder(symbol string, side Side, quantity Decimal, opts ...OrderOption) CreateOrder {
+	return newOrder(CreateOrder{
+		Symbol:    symbol,
+		Side:      side,
+		OrderType: OrderTypeMarket,
+		Quantity:  quantity,
+	}, opts)
+}
+
+// MarketOrderQuote 创建按计价资产金额成交的市价单，例如用 100 USDC 买入 SOL
+func MarketOrderQuote(symbol string, side Side, quoteQuantity Decimal, opts ...OrderOption) CreateOrder {
+	return newOrder(CreateOrder{
+		Symbol:        symbol,
+		Side:          side,
+		OrderType:     OrderTypeMarket,
+		QuoteQuantity: quoteQuantity,
+	}, opts)
+}
+
+func newOrder(co CreateOrder, opts []OrderOption) CreateOrder {
+	for _, opt := range opts {
+		opt(&co)
+	}
+	return co
+}
+
+// PostOnly 只挂单，会立即成交时订单被拒绝
+func PostOnly() OrderOption {
+	return func(co *CreateOrder) { co.PostOnly = true }
+}
+
+// GTC 设置 timeInForce 为 GTC
+func GTC() OrderOption {
+	return func(co *CreateOrder) { co.TimeInForce = TimeInForceGTC }
+}
+
+// IOC 设置 timeInForce 为 IOC，未能立即成交的部分撤销
+func IOC() OrderOption {
+	return func(co *CreateOrder) { co.TimeInForce = TimeInForceIOC }
+}
+
+// FOK 设置 timeInForce 为 FOK，不能全部立即成交时撤销
+func FOK() OrderOption {
+	return func(co *CreateOrder) { co.TimeInForce = TimeInForceFOK }
+}
+
+// TriggerAt 设置触发价格，价格到达后订单才进入订单簿
+func TriggerAt(price Decimal) OrderOption {
+	return func(co *CreateOrder) { co.TriggerPrice = price }
+}
+
+// ClientID 设置客户端订单 ID，带 clientId 的订单在失败时可以安全重试
+func ClientID(id uint32) OrderOption {
+	return func(co *CreateOrder) { co.ClientId = id }
+}
+
+// SelfTrade 设置自成交的处理方式
+func SelfTrade(p SelfTradePrevention) OrderOption {
+	return func(co *CreateOrder) { co.SelfTradePrevention = p }
+}
+
+// BatchOrderResult 为批量下单中单个订单的结果，Order 和 Err 只有一个非空
+type BatchOrderResult struct {
+	Order *Order
+	Err   error
+}
+
+// batchOrderItem 为批量下单响应中的一项，成功时为订单，失败时带 code 和 message
+type batchOrderItem struct {
+	Order
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// 批量执行订单，所有订单共用一个签名。
+// 本地校验（Client.Markets 非空时包括市场限制）失败的订单不会发送，其结果的 Err 包装 ErrInvalidOrder；
+// 返回的结果与 orders 一一对应。只有整个请求或读取市场列表失败时才返回 error。
+func (c *Client) CreateOrders(
+	ctx context.Context,
+	orders []CreateOrder,
+) ([]BatchOrderResult, error) {
+	url := "/api/v1/orders"
+
+	results := make([]BatchOrderResult, len(orders))
+	var (
+		sent         []int
+		body         []map[string]interface{}
+		instructions []Instruction
+		idempotent   = true // 所有订单都带 clientId 时才重试
+	)
+	for i, co := range orders {
+		if err := c.validateOrder(ctx, co); err != nil {
+			if !errors.Is(err, ErrInvalidOrder) {
+				return nil, err
+			}
+			results[i].Err = fmt.Errorf("order %d: %w", i, err)
+			continue
+		}
+		params := co.params()
+		idempotent = idempotent && co.ClientId != 0
+		sent = append(sent, i)
+		body = append(body, params)
+		instructions = append(instructions, Instruction{Name: "orderExecute", Params: params})
+	}
+	if len(sent) == 0 {
+		return results, nil
+	}
+
+	var items []json.RawMessage
+	r := request{
+		method:       http.MethodPost,
+		path:         url,
+		params:       body,
+		instructions: instructions,
+		idempotent:   idempotent,
+	}
+	if err := c.decodeRequest(ctx, r, &items); err != nil {
+		return nil, err
+	}
+	if len(items) != len(sent) {
+		return nil, fmt.Errorf("backpack: POST %s: got %d results for %d orders", url, len(items), len(sent))
+	}
+
+	for j, raw := range items {
+		i := sent[j]
+		var item batchOrderItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			results[i].Err = fmt.Errorf("backpack: POST %s: decode result %d: %w", url, j, err)
+			continue
+		}
+		if item.Code != "" {
+			results[i].Err = &APIError{StatusCode: http.StatusOK, Code: item.Code, Message: item.Message, Path: url}
+			continue
+		}
+		order := item.Order
+		results[i].Order = &order
+	}
+	return results, nil
+}
@@ -0,0 +1,258 @@
+package backpack_interface
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestClient 创建指向 handler 的客户端，不限流、不重试、不按市场限制校验
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(Key{APIKey: testAPIKey, Secret: testSecret})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.BaseURL = srv.URL
+	c.Limiter = nil
+	c.Retry = nil
+	c.Markets = nil
+	return c
+}
+
+// verifySignature 校验请求头中的签名覆盖了 instructions
+func verifySignature(t *testing.T, c *Client, h http.Header, instructions []Instruction) {
+	t.Helper()
+	ts, err1 := strconv.ParseInt(h.Get("X-Timestamp"), 10, 64)
+	window, err2 := strconv.ParseInt(h.Get("X-Window"), 10, 64)
+	sig, err3 := base64.StdEncoding.DecodeString(h.Get("X-Signature"))
+	if err1 != nil || err2 != nil || err3 != nil {
+		t.Fatalf("bad signature headers: %v", h)
+	}
+	msg := signString(instructions, ts, window)
+	if !ed25519.Verify(c.Signer.PublicKey(), []byte(msg), sig) {
+		t.Errorf("signature does not cover\n%s", msg)
+	}
+}
+
+func TestCreateOrders(t *testing.T) {
+	d := MustDecimal
+	orders := []CreateOrder{
+		{Symbol: "SOL_USDC", Side: "Bid", OrderType: "Limit", Price: d("10"), Quantity: d("1")},
+		{Symbol: "SOL_USDC", Side: "buy", OrderType: "Limit", Price: d("10"), Quantity: d("1")},
+		{Symbol: "SOL_USDC", Side: "Ask", OrderType: "Market", Quantity: d("2")},
+		{Symbol: "SOL_USDC", Side: "Bid", OrderType: "Limit", Price: d("9"), Quantity: d("100")},
+	}
+	var (
+		header http.Header
+		body   []map[string]interface{}
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/orders" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		header = r.Header
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("body %s: %v", b, err)
+		}
+		w.Write([]byte(`[{"id":"1","status":"New"},{"id":"2","status":"Filled"},` +
+			`{"code":"INSUFFICIENT_FUNDS","message":"Insufficient funds"}]`))
+	})
+
+	results, err := c.CreateOrders(context.Background(), orders)
+	if err != nil {
+		t.Fatalf("CreateOrders: %v", err)
+	}
+	if len(results) != len(orders) {
+		t.Fatalf("got %d results, want %d", len(results), len(orders))
+	}
+	// 结果与输入按下标对应，本地校验失败的订单不发送
+	if r := results[0]; r.Err != nil || r.Order == nil || r.Order.ID != "1" {
+		t.Errorf("results[0] = %+v, want order 1", r)
+	}
+	if r := results[1]; r.Order != nil || !errors.Is(r.Err, ErrInvalidOrder) || !strings.HasPrefix(r.Err.Error(), "order 1:") {
+		t.Errorf("results[1] = %+v, want ErrInvalidOrder for order 1", r)
+	}
+	if r := results[2]; r.Err != nil || r.Order == nil || r.Order.ID != "2" {
+		t.Errorf("results[2] = %+v, want order 2", r)
+	}
+	var apiErr *APIError
+	if r := results[3]; r.Order != nil || !errors.As(r.Err, &apiErr) ||
+		apiErr.Code != "INSUFFICIENT_FUNDS" || apiErr.Message != "Insufficient funds" {
+		t.Errorf("results[3] = %+v, want APIError INSUFFICIENT_FUNDS", r)
+	}
+
+	if len(body) != 3 || body[0]["side"] != "Bid" || body[1]["orderType"] != "Market" || body[2]["price"] != "9" {
+		t.Errorf("body = %v, want orders 0, 2 and 3", body)
+	}
+	// 一个签名覆盖所有发送的订单
+	verifySignature(t, c, header, []Instruction{
+		{Name: "orderExecute", Params: orders[0].params()},
+		{Name: "orderExecute", Params: orders[2].params()},
+		{Name: "orderExecute", Params: orders[3].params()},
+	})
+}
+
+func TestCreateOrdersResultCount(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"1"}]`))
+	})
+	order := CreateOrder{Symbol: "SOL_USDC", Side: "Bid", OrderType: "Limit", Price: MustDecimal("10"), Quantity: MustDecimal("1")}
+	_, err := c.CreateOrders(context.Background(), []CreateOrder{order, order})
+	if err == nil || !strings.Contains(err.Error(), "got 1 results for 2 orders") {
+		t.Fatalf("err = %v, want result count mismatch", err)
+	}
+}
+
+func TestCreateOrdersAllInvalid(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	results, err := c.CreateOrders(context.Background(), []CreateOrder{{Symbol: "SOL_USDC"}, {Side: "Bid"}})
+	if err != nil {
+		t.Fatalf("CreateOrders: %v", err)
+	}
+	for i, r := range results {
+		if !errors.Is(r.Err, ErrInvalidOrder) {
+			t.Errorf("results[%d].Err = %v, want ErrInvalidOrder", i, r.Err)
+		}
+	}
+}
+
+func TestOrderConstructors(t *testing.T) {
+	d := MustDecimal
+	tests := []struct {
+		name string
+		got  CreateOrder
+		want CreateOrder
+	}{
+		{
+			name: "limit",
+			got:  LimitOrder("SOL_USDC", SideBid, d("10.5"), d("2")),
+			want: CreateOrder{Symbol: "SOL_USDC", Side: SideBid, OrderType: OrderTypeLimit, Price: d("10.5"), Quantity: d("2")},
+		},
+		{
+			name: "limit with options",
+			got:  LimitOrder("SOL_USDC", SideAsk, d("11"), d("1"), PostOnly(), ClientID(42), SelfTrade(SelfTradeRejectBoth)),
+			want: CreateOrder{Symbol: "SOL_USDC", Side: SideAsk, OrderType: OrderTypeLimit, Price: d("11"), Quantity: d("1"),
+				PostOnly: true, ClientId: 42, SelfTradePrevention: SelfTradeRejectBoth},
+		},
+		{
+			name: "market",
+			got:  MarketOrder("SOL_USDC", SideAsk, d("3"), IOC()),
+			want: CreateOrder{Symbol: "SOL_USDC", Side: SideAsk, OrderType: OrderTypeMarket, Quantity: d("3"), TimeInForce: TimeInForceIOC},
+		},
+		{
+			name: "market quote",
+			got:  MarketOrderQuote("SOL_USDC", SideBid, d("100"), FOK(), TriggerAt(d("9"))),
+			want: CreateOrder{Symbol: "SOL_USDC", Side: SideBid, OrderType: OrderTypeMarket, QuoteQuantity: d("100"),
+				TimeInForce: TimeInForceFOK, TriggerPrice: d("9")},
+		},
+		{
+			name: "last option wins",
+			got:  LimitOrder("SOL_USDC", SideBid, d("1"), d("1"), IOC(), GTC()),
+			want: CreateOrder{Symbol: "SOL_USDC", Side: SideBid, OrderType: OrderTypeLimit, Price: d("1"), Quantity: d("1"),
+				TimeInForce: TimeInForceGTC},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", tt.got, tt.want)
+			}
+			if err := tt.got.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestOrderParamsOmitUnset(t *testing.T) {
+	d := MustDecimal
+	tests := []struct {
+		name     string
+		order    CreateOrder
+		wantBody string
+		wantSign string
+	}{
+		{
+			name:     "limit",
+			order:    LimitOrder("SOL_USDC", SideBid, d("10.5"), d("2")),
+			wantBody: `{"orderType":"Limit","price":"10.5","quantity":"2","side":"Bid","symbol":"SOL_USDC"}`,
+			wantSign: "instruction=orderExecute&orderType=Limit&price=10.5&quantity=2&side=Bid&symbol=SOL_USDC",
+		},
+		{
+			name:  "limit with options",
+			order: LimitOrder("SOL_USDC", SideBid, d("10.5"), d("2"), PostOnly(), ClientID(42)),
+			wantBody: `{"clientId":42,"orderType":"Limit","postOnly":true,"price":"10.5","quantity":"2",` +
+				`"side":"Bid","symbol":"SOL_USDC"}`,
+			wantSign: "instruction=orderExecute&clientId=42&orderType=Limit&postOnly=true&price=10.5&quantity=2" +
+				"&side=Bid&symbol=SOL_USDC",
+		},
+		{
+			name:  "market quote",
+			order: MarketOrderQuote("SOL_USDC", SideAsk, d("100"), IOC(), TriggerAt(d("9"))),
+			wantBody: `{"orderType":"Market","quoteQuantity":"100","side":"Ask","symbol":"SOL_USDC",` +
+				`"timeInForce":"IOC","triggerPrice":"9"}`,
+			wantSign: "instruction=orderExecute&orderType=Market&quoteQuantity=100&side=Ask&symbol=SOL_USDC" +
+				"&timeInForce=IOC&triggerPrice=9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.order.params())
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body =\n%s\nwant\n%s", body, tt.wantBody)
+			}
+			sign := signString([]Instruction{{Name: "orderExecute", Params: tt.order.params()}}, testTimestamp, 5000)
+			if want := tt.wantSign + "&timestamp=1700000000000&window=5000"; sign != want {
+				t.Errorf("signString() =\n%s\nwant\n%s", sign, want)
+			}
+		})
+	}
+}
+
+func TestCreateOrderBody(t *testing.T) {
+	var body map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/order" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("body %s: %v", b, err)
+		}
+		w.Write([]byte(`{"id":"111","clientId":42,"status":"New"}`))
+	})
+	order, err := c.CreateOrder(context.Background(),
+		LimitOrder("SOL_USDC", SideBid, MustDecimal("10"), MustDecimal("1"), ClientID(42)))
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if order.ID != "111" || order.ClientID != 42 {
+		t.Errorf("order = %+v", order)
+	}
+	if body["clientId"] != float64(42) {
+		t.Errorf("clientId = %#v, want number 42", body["clientId"])
+	}
+	for _, key := range []string{"postOnly", "quoteQuantity", "triggerPrice", "timeInForce", "selfTradePrevention"} {
+		if _, ok := body[key]; ok {
+			t.Errorf("unset field %s sent: %v", key, body)
+		}
+	}
+}
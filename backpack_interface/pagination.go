@@ -0,0 +1,212 @@
+package backpack_interface
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPageSize 为分页迭代时每页的默认条数
+const DefaultPageSize = 100
+
+// PageOptions 为分页迭代的通用配置
+type PageOptions struct {
+	PageSize int // 每页条数，为 0 时使用 DefaultPageSize
+	MaxCount int // 最多返回的条数，为 0 时不限制
+}
+
+// Iterator 自动翻页遍历历史记录，用法与 bufio.Scanner 相同：
+//
+//	it := client.FillsIter(ctx, FillFilter{Symbol: "SOL_USDC"})
+//	for it.Next() {
+//		fill := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		// 处理错误
+//	}
+//
+// 某一页返回的条数少于页大小时认为已经到达末尾。Iterator 不能并发使用。
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, limit, offset int) ([]T, error)
+	opts  PageOptions
+
+	page   []T
+	offset int
+	count  int
+	cur    T
+	last   bool
+	err    error
+}
+
+func newIterator[T any](
+	ctx context.Context,
+	opts PageOptions,
+	fetch func(ctx context.Context, limit, offset int) ([]T, error),
+) *Iterator[T] {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	return &Iterator[T]{ctx: ctx, fetch: fetch, opts: opts}
+}
+
+// Next 前进到下一条记录，没有更多记录或出错时返回 false
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.opts.MaxCount > 0 && it.count >= it.opts.MaxCount {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.last {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		limit := it.opts.PageSize
+		if rest := it.opts.MaxCount - it.count; it.opts.MaxCount > 0 && rest < limit {
+			limit = rest
+		}
+		page, err := it.fetch(it.ctx, limit, it.offset)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.offset += len(page)
+		it.last = len(page) < limit
+		it.page = page
+		if len(page) == 0 {
+			return false
+		}
+	}
+	it.cur = it.page[0]
+	it.page = it.page[1:]
+	it.count++
+	return true
+}
+
+// Value 返回当前记录
+func (it *Iterator[T]) Value() T {
+	return it.cur
+}
+
+// Err 返回迭代过程中遇到的第一个错误
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All 读取剩余的全部记录
+func (it *Iterator[T]) All() ([]T, error) {
+	var rst []T
+	for it.Next() {
+		rst = append(rst, it.Value())
+	}
+	return rst, it.Err()
+}
+
+// millis 将时间转换为毫秒时间戳，零值返回 0 表示不限制
+func millis(t time.Time) int {
+	if t.IsZero() {
+		return 0
+	}
+	return int(t.UnixMilli())
+}
+
+// OrderFilter 为订单历史的查询条件
+type OrderFilter struct {
+	OrderId string
+	Symbol  string
+	PageOptions
+}
+
+// FillFilter 为成交历史的查询条件，From 和 To 为零值时不限制
+type FillFilter struct {
+	OrderId string
+	Symbol  string
+	From    time.Time
+	To      time.Time
+	PageOptions
+}
+
+// DepositFilter 为存款历史的查询条件，From 和 To 为零值时不限制
+type DepositFilter struct {
+	From time.Time
+	To   time.Time
+	PageOptions
+}
+
+// WithdrawalFilter 为提款历史的查询条件，From 和 To 为零值时不限制
+type WithdrawalFilter struct {
+	From time.Time
+	To   time.Time
+	PageOptions
+}
+
+// TradeFilter 为公开历史成交的查询条件
+type TradeFilter struct {
+	Symbol string
+	PageOptions
+}
+
+// 遍历订单历史
+func (c *Client) OrdersIter(ctx context.Context, f OrderFilter) *Iterator[Order] {
+	return newIterator(ctx, f.PageOptions, func(ctx context.Context, limit, offset int) ([]Order, error) {
+		return c.GetOrderHistory(ctx, OrderHistory{
+			OrderId: f.OrderId,
+			Symbol:  f.Symbol,
+			Limit:   limit,
+			Offset:  offset,
+		})
+	})
+}
+
+// 遍历成交历史
+func (c *Client) FillsIter(ctx context.Context, f FillFilter) *Iterator[Fill] {
+	return newIterator(ctx, f.PageOptions, func(ctx context.Context, limit, offset int) ([]Fill, error) {
+		return c.GetFillHistory(ctx, FillHistory{
+			OrderId: f.OrderId,
+			Symbol:  f.Symbol,
+			From:    millis(f.From),
+			To:      millis(f.To),
+			Limit:   limit,
+			Offset:  offset,
+		})
+	})
+}
+
+// 遍历存款历史
+func (c *Client) DepositsIter(ctx context.Context, f DepositFilter) *Iterator[Deposit] {
+	return newIterator(ctx, f.PageOptions, func(ctx context.Context, limit, offset int) ([]Deposit, error) {
+		return c.GetDepositeHistory(ctx, DepositeHistory{
+			From:   millis(f.From),
+			To:     millis(f.To),
+			Limit:  limit,
+			Offset: offset,
+		})
+	})
+}
+
+// 遍历提款历史
+func (c *Client) WithdrawalsIter(ctx context.Context, f WithdrawalFilter) *Iterator[Withdrawal] {
+	return newIterator(ctx, f.PageOptions, func(ctx context.Context, limit, offset int) ([]Withdrawal, error) {
+		return c.GetWithdrawHistory(ctx, WithdrawHistory{
+			From:   millis(f.From),
+			To:     millis(f.To),
+			Limit:  limit,
+			Offset: offset,
+		})
+	})
+}
+
+// 遍历公开历史成交
+func (c *Client) HistoricalTradesIter(ctx context.Context, f TradeFilter) *Iterator[Trade] {
+	return newIterator(ctx, f.PageOptions, func(ctx context.Context, limit, offset int) ([]Trade, error) {
+		return c.GetHistoricalTrades(ctx, HistoryTrades{
+			Symbol: f.Symbol,
+			Limit:  limit,
+			Offset: offset,
+		})
+	})
+}
@@ -0,0 +1,183 @@
+package backpack_interface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pageCall 记录一次翻页请求
+type pageCall struct{ limit, offset int }
+
+// fakePages 返回 total 条记录的翻页函数，failAt 次请求（从 1 开始）返回错误
+func fakePages(total, failAt int, calls *[]pageCall) func(context.Context, int, int) ([]int, error) {
+	return func(ctx context.Context, limit, offset int) ([]int, error) {
+		*calls = append(*calls, pageCall{limit, offset})
+		if len(*calls) == failAt {
+			return nil, errors.New("boom")
+		}
+		var page []int
+		for i := offset; i < total && i < offset+limit; i++ {
+			page = append(page, i)
+		}
+		return page, nil
+	}
+}
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		opts      PageOptions
+		wantCount int
+		wantCalls []pageCall
+	}{
+		{"empty", 0, PageOptions{PageSize: 10}, 0, []pageCall{{10, 0}}},
+		{"short last page", 25, PageOptions{PageSize: 10}, 25, []pageCall{{10, 0}, {10, 10}, {10, 20}}},
+		{"exact pages", 20, PageOptions{PageSize: 10}, 20, []pageCall{{10, 0}, {10, 10}, {10, 20}}},
+		{"default page size", 150, PageOptions{}, 150, []pageCall{{100, 0}, {100, 100}}},
+		{"max count", 100, PageOptions{PageSize: 10, MaxCount: 25}, 25, []pageCall{{10, 0}, {10, 10}, {5, 20}}},
+		{"max count above total", 15, PageOptions{PageSize: 10, MaxCount: 50}, 15, []pageCall{{10, 0}, {10, 10}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []pageCall
+			got, err := newIterator(context.Background(), tt.opts, fakePages(tt.total, 0, &calls)).All()
+			if err != nil {
+				t.Fatalf("All: %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Fatalf("got %d records, want %d", len(got), tt.wantCount)
+			}
+			for i, v := range got {
+				if v != i {
+					t.Fatalf("record %d = %d, records out of order", i, v)
+				}
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIteratorError(t *testing.T) {
+	var calls []pageCall
+	it := newIterator(context.Background(), PageOptions{PageSize: 10}, fakePages(100, 2, &calls))
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 10 || it.Err() == nil || it.Err().Error() != "boom" {
+		t.Fatalf("read %d records, Err = %v; want 10 and boom", n, it.Err())
+	}
+	// 出错后不再请求
+	if it.Next() || len(calls) != 2 {
+		t.Fatalf("Next after error fetched again: %v", calls)
+	}
+}
+
+func TestIteratorContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls []pageCall
+	it := newIterator(ctx, PageOptions{PageSize: 10}, fakePages(100, 0, &calls))
+	for i := 0; i < 10; i++ {
+		if !it.Next() {
+			t.Fatalf("Next %d = false: %v", i, it.Err())
+		}
+	}
+	cancel()
+	if it.Next() || !errors.Is(it.Err(), context.Canceled) || len(calls) != 1 {
+		t.Fatalf("Err = %v calls = %v, want context.Canceled after 1 call", it.Err(), calls)
+	}
+}
+
+// pagedServer 模拟分页接口，每个路径有 total 条记录，记录收到的 limit/offset
+func pagedServer(t *testing.T, total int, item func(i int) string) (*Client, *[]pageCall) {
+	var calls []pageCall
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit, err1 := strconv.Atoi(q.Get("limit"))
+		offset, err2 := strconv.Atoi(q.Get("offset"))
+		if err1 != nil || err2 != nil {
+			t.Errorf("%s: bad limit/offset in %q", r.URL.Path, r.URL.RawQuery)
+		}
+		calls = append(calls, pageCall{limit, offset})
+		var items []string
+		for i := offset; i < total && i < offset+limit; i++ {
+			items = append(items, item(i))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+	})
+	return c, &calls
+}
+
+func TestHistoryIterOffsets(t *testing.T) {
+	opts := PageOptions{PageSize: 2, MaxCount: 5}
+	wantCalls := []pageCall{{2, 0}, {2, 2}, {1, 4}}
+	ctx := context.Background()
+
+	t.Run("fills", func(t *testing.T) {
+		c, calls := pagedServer(t, 10, func(i int) string { return fmt.Sprintf(`{"tradeId":%d}`, i) })
+		fills, err := c.FillsIter(ctx, FillFilter{Symbol: "SOL_USDC", PageOptions: opts}).All()
+		if err != nil {
+			t.Fatalf("FillsIter: %v", err)
+		}
+		if len(fills) != 5 || fills[4].TradeID != 4 {
+			t.Fatalf("fills = %+v", fills)
+		}
+		if !reflect.DeepEqual(*calls, wantCalls) {
+			t.Errorf("calls = %v, want %v", *calls, wantCalls)
+		}
+	})
+	t.Run("withdrawals", func(t *testing.T) {
+		c, calls := pagedServer(t, 3, func(i int) string { return fmt.Sprintf(`{"id":%d}`, i) })
+		ws, err := c.WithdrawalsIter(ctx, WithdrawalFilter{PageOptions: opts}).All()
+		if err != nil {
+			t.Fatalf("WithdrawalsIter: %v", err)
+		}
+		if len(ws) != 3 || ws[2].ID != 2 {
+			t.Fatalf("withdrawals = %+v", ws)
+		}
+		if want := []pageCall{{2, 0}, {2, 2}}; !reflect.DeepEqual(*calls, want) {
+			t.Errorf("calls = %v, want %v", *calls, want)
+		}
+	})
+	t.Run("historical trades", func(t *testing.T) {
+		c, calls := pagedServer(t, 10, func(i int) string { return fmt.Sprintf(`{"id":%d}`, i) })
+		trades, err := c.HistoricalTradesIter(ctx, TradeFilter{Symbol: "SOL_USDC", PageOptions: opts}).All()
+		if err != nil {
+			t.Fatalf("HistoricalTradesIter: %v", err)
+		}
+		if len(trades) != 5 || trades[4].ID != 4 {
+			t.Fatalf("trades = %+v", trades)
+		}
+		if !reflect.DeepEqual(*calls, wantCalls) {
+			t.Errorf("calls = %v, want %v", *calls, wantCalls)
+		}
+	})
+}
+
+func TestHistoryIterError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "2" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"INVALID_CLIENT_REQUEST","message":"bad offset"}`))
+			return
+		}
+		w.Write([]byte(`[{"tradeId":1},{"tradeId":2}]`))
+	})
+	fills, err := c.FillsIter(context.Background(), FillFilter{PageOptions: PageOptions{PageSize: 2}}).All()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "INVALID_CLIENT_REQUEST" {
+		t.Fatalf("err = %v, want APIError", err)
+	}
+	if len(fills) != 2 {
+		t.Fatalf("got %d fills before the error, want 2", len(fills))
+	}
+}
@@ -0,0 +1,169 @@
+package backpack_interface
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit 为令牌桶配置：每秒补充 Rate 个令牌，最多积攒 Burst 个
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// 默认的请求预算，公开接口和签名接口分开计算
+var (
+	DefaultPublicRateLimit = RateLimit{Rate: 20, Burst: 40}
+	DefaultSignedRateLimit = RateLimit{Rate: 10, Burst: 20}
+)
+
+// defaultRetryAfter 为 429 响应没有 Retry-After 时的暂停时间
+const defaultRetryAfter = time.Second
+
+// RateLimiterStats 为限流器的运行指标
+type RateLimiterStats struct {
+	PublicTokens  float64       // 公开接口当前可用令牌
+	SignedTokens  float64       // 签名接口当前可用令牌
+	Requests      uint64        // 通过限流器的请求数
+	Delayed       uint64        // 需要等待令牌的请求数
+	WaitTime      time.Duration // 累计等待时间
+	RateLimited   uint64        // 收到 429 的次数
+	CooldownUntil time.Time     // 收到 429 后暂停到的时间，零值表示未暂停
+}
+
+// RateLimiter 为客户端令牌桶限流器，可在多个 Client 之间共享。
+// 收到 429 后所有请求暂停到 Retry-After 指定的时间。
+type RateLimiter struct {
+	mu            sync.Mutex
+	public        tokenBucket
+	signed        tokenBucket
+	cooldownUntil time.Time
+	stats         RateLimiterStats
+	now           func() time.Time
+}
+
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter 创建限流器，令牌桶初始为满
+func NewRateLimiter(public, signed RateLimit) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		public: tokenBucket{limit: public, tokens: float64(public.Burst), last: now},
+		signed: tokenBucket{limit: signed, tokens: float64(signed.Burst), last: now},
+		now:    time.Now,
+	}
+}
+
+// refill 按经过的时间补充令牌
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.limit.Rate
+		if burst := float64(b.limit.Burst); b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+}
+
+// reserve 预留 n 个令牌并返回需要等待的时间，令牌可以透支
+func (b *tokenBucket) reserve(now time.Time, n float64) time.Duration {
+	if b.limit.Rate <= 0 {
+		return 0
+	}
+	b.refill(now)
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.limit.Rate * float64(time.Second))
+}
+
+// Wait 阻塞直到可以发送一个权重为 weight 的请求，ctx 取消时返回其错误
+func (l *RateLimiter) Wait(ctx context.Context, signed bool, weight int) error {
+	if weight <= 0 {
+		weight = 1
+	}
+	l.mu.Lock()
+	now := l.now()
+	bucket := &l.public
+	if signed {
+		bucket = &l.signed
+	}
+	delay := bucket.reserve(now, float64(weight))
+	if cooldown := l.cooldownUntil.Sub(now); cooldown > delay {
+		delay = cooldown
+	}
+	l.stats.Requests++
+	if delay > 0 {
+		l.stats.Delayed++
+		l.stats.WaitTime += delay
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// 退还未使用的令牌
+		l.mu.Lock()
+		bucket.tokens += float64(weight)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Backoff 在收到 429 后暂停所有请求 d 时间
+func (l *RateLimiter) Backoff(d time.Duration) {
+	if d <= 0 {
+		d = defaultRetryAfter
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.RateLimited++
+	if until := l.now().Add(d); until.After(l.cooldownUntil) {
+		l.cooldownUntil = until
+	}
+}
+
+// Stats 返回限流器当前状态的快照
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.public.refill(now)
+	l.signed.refill(now)
+	stats := l.stats
+	stats.PublicTokens = l.public.tokens
+	stats.SignedTokens = l.signed.tokens
+	if l.cooldownUntil.After(now) {
+		stats.CooldownUntil = l.cooldownUntil
+	}
+	return stats
+}
+
+// parseRetryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,167 @@
+package backpack_interface
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestLimiter 创建使用可控时钟的限流器，返回推进时钟的函数
+func newTestLimiter(public, signed RateLimit) (*RateLimiter, func(time.Duration)) {
+	now := time.Unix(1700000000, 0)
+	l := NewRateLimiter(public, signed)
+	l.now = func() time.Time { return now }
+	l.public.last = now
+	l.signed.last = now
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l, advance := newTestLimiter(RateLimit{Rate: 10, Burst: 10}, RateLimit{Rate: 1, Burst: 2})
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(ctx, false, 1); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+	}
+	s := l.Stats()
+	if s.PublicTokens != 0 || s.SignedTokens != 2 || s.Requests != 10 || s.Delayed != 0 {
+		t.Fatalf("stats = %+v, want public bucket drained without delay", s)
+	}
+
+	advance(500 * time.Millisecond)
+	if got := l.Stats().PublicTokens; got != 5 {
+		t.Errorf("PublicTokens after 500ms = %v, want 5", got)
+	}
+	// 补充不超过 Burst
+	advance(time.Minute)
+	if s := l.Stats(); s.PublicTokens != 10 || s.SignedTokens != 2 {
+		t.Errorf("tokens after 1m = %v/%v, want 10/2", s.PublicTokens, s.SignedTokens)
+	}
+}
+
+func TestRateLimiterWeight(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{Rate: 1, Burst: 10}, RateLimit{Rate: 1, Burst: 10})
+	ctx := context.Background()
+	if err := l.Wait(ctx, true, 3); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	// 权重不大于 0 时按 1 计算
+	if err := l.Wait(ctx, true, 0); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if s := l.Stats(); s.SignedTokens != 6 || s.PublicTokens != 10 {
+		t.Errorf("tokens = %v/%v, want signed 6 public 10", s.SignedTokens, s.PublicTokens)
+	}
+}
+
+func TestRateLimiterOverdraft(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{Rate: 100, Burst: 1}, DefaultSignedRateLimit)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, false, 1); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+	}
+	// 第 2、3 个请求透支令牌，分别等待 10ms 和 20ms
+	s := l.Stats()
+	if s.PublicTokens != -2 || s.Delayed != 2 || s.WaitTime != 30*time.Millisecond {
+		t.Errorf("stats = %+v, want tokens -2, 2 delayed, 30ms waited", s)
+	}
+}
+
+func TestRateLimiterRefundOnCancel(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{Rate: 1, Burst: 1}, DefaultSignedRateLimit)
+	if err := l.Wait(context.Background(), false, 1); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, false, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, want context.Canceled", err)
+	}
+	if s := l.Stats(); s.PublicTokens != 0 || s.Delayed != 1 {
+		t.Errorf("stats = %+v, want reserved tokens refunded", s)
+	}
+}
+
+func TestRateLimiterBackoff(t *testing.T) {
+	l, advance := newTestLimiter(DefaultPublicRateLimit, DefaultSignedRateLimit)
+	start := l.now()
+
+	l.Backoff(5 * time.Second)
+	l.Backoff(time.Second) // 较短的暂停不会缩短已有的暂停
+	s := l.Stats()
+	if !s.CooldownUntil.Equal(start.Add(5*time.Second)) || s.RateLimited != 2 {
+		t.Fatalf("stats = %+v, want cooldown until +5s and 2 rate limited", s)
+	}
+
+	// 暂停期间即使有令牌也要等待
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, true, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait during cooldown = %v, want context.Canceled", err)
+	}
+	if s := l.Stats(); s.WaitTime != 5*time.Second || s.SignedTokens != float64(DefaultSignedRateLimit.Burst) {
+		t.Errorf("stats = %+v, want 5s wait and refunded token", s)
+	}
+
+	advance(5 * time.Second)
+	if err := l.Wait(ctx, true, 1); err != nil {
+		t.Fatalf("Wait after cooldown: %v", err)
+	}
+	if s := l.Stats(); !s.CooldownUntil.IsZero() {
+		t.Errorf("CooldownUntil = %v after cooldown, want zero", s.CooldownUntil)
+	}
+
+	// 没有 Retry-After 时使用默认暂停时间
+	l.Backoff(0)
+	if s := l.Stats(); !s.CooldownUntil.Equal(l.now().Add(defaultRetryAfter)) {
+		t.Errorf("CooldownUntil = %v, want now + %v", s.CooldownUntil, defaultRetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"2", 2 * time.Second},
+		{"0.5", 500 * time.Millisecond},
+		{now.Add(3 * time.Second).Format(http.TimeFormat), 3 * time.Second},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			h := http.Header{}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+			if got := parseRetryAfter(h, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientRateLimited(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	l, _ := newTestLimiter(DefaultPublicRateLimit, DefaultSignedRateLimit)
+	c.Limiter = l
+
+	_, err := c.GetMarkets(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != 2*time.Second {
+		t.Fatalf("err = %v, want 429 with RetryAfter 2s", err)
+	}
+	if s := l.Stats(); s.RateLimited != 1 || !s.CooldownUntil.Equal(l.now().Add(2*time.Second)) {
+		t.Errorf("stats = %+v, want cooldown for 2s", s)
+	}
+}
@@ -0,0 +1,70 @@
+package backpack_interface
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy 决定失败的请求是否重试以及重试前等待多久。
+// attempt 为已经失败的次数，从 1 开始。
+// 只有幂等的请求才会询问 RetryPolicy：GET、撤单，以及带 clientId 的下单。
+// 每次重试都会重新签名。
+type RetryPolicy interface {
+	Retry(attempt int, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff 为带随机抖动的指数退避策略
+type ExponentialBackoff struct {
+	MaxAttempts int           // 最多尝试次数，包括第一次
+	BaseDelay   time.Duration // 第一次重试前的等待时间
+	MaxDelay    time.Duration // 等待时间上限，为 0 时不限制
+	Jitter      float64       // 抖动比例，0.2 表示在 ±20% 范围内随机
+}
+
+// DefaultRetryPolicy 为 NewClient 使用的默认重试策略
+var DefaultRetryPolicy = ExponentialBackoff{
+	MaxAttempts: 4,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.2,
+}
+
+// Retry 实现 RetryPolicy，只重试 IsRetryable 的错误；429 至少等待 Retry-After
+func (b ExponentialBackoff) Retry(attempt int, err error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts || !IsRetryable(err) {
+		return 0, false
+	}
+
+	delay := b.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if b.MaxDelay > 0 && delay >= b.MaxDelay {
+			break
+		}
+	}
+	if b.MaxDelay > 0 && delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+	if b.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * b.Jitter * float64(delay))
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+		delay = apiErr.RetryAfter
+	}
+	return delay, true
+}
+
+// IsRetryable 判断错误是否可以重试：网络错误、429 和 5xx
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
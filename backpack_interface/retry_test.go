@@ -0,0 +1,193 @@
+package backpack_interface
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fastRetry 为测试用的重试策略，不等待
+var fastRetry = ExponentialBackoff{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+// flakyServer 依次返回 statuses 中的状态码，用完后返回 200 和 body，返回记录请求头的函数
+func flakyServer(t *testing.T, body string, statuses ...int) (*Client, func() []http.Header) {
+	var (
+		mu      sync.Mutex
+		headers []http.Header
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n := len(headers)
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+		if n < len(statuses) {
+			switch statuses[n] {
+			case 0:
+				// 断开连接，模拟网络错误
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+			case http.StatusTooManyRequests:
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(statuses[n])
+			default:
+				w.WriteHeader(statuses[n])
+			}
+			return
+		}
+		w.Write([]byte(body))
+	})
+	c.Retry = fastRetry
+	return c, func() []http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return headers
+	}
+}
+
+func TestRetryRules(t *testing.T) {
+	d := MustDecimal
+	getMarkets := func(c *Client) error {
+		_, err := c.GetMarkets(context.Background())
+		return err
+	}
+	cancel := func(c *Client) error {
+		_, err := c.CancelOpenOrder(context.Background(), CancelTokenOrder{Symbol: "SOL_USDC", OrderId: "111"})
+		return err
+	}
+	createOrder := func(clientID uint32) func(c *Client) error {
+		return func(c *Client) error {
+			_, err := c.CreateOrder(context.Background(), CreateOrder{Symbol: "SOL_USDC", Side: "Bid",
+				OrderType: "Limit", Price: d("10"), Quantity: d("1"), ClientId: clientID})
+			return err
+		}
+	}
+	withdraw := func(c *Client) error {
+		_, err := c.RequestWithdrawal(context.Background(), RequestWithdraw{Address: "addr", Blockchain: "Solana",
+			ClientId: "w1", Quantity: d("1"), Symbol: "SOL"})
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		call     func(*Client) error
+		body     string
+		statuses []int
+		attempts int
+		wantErr  bool
+	}{
+		{"GET 5xx", getMarkets, `[]`, []int{500, 502}, 3, false},
+		{"GET 429", getMarkets, `[]`, []int{429}, 2, false},
+		{"GET network error", getMarkets, `[]`, []int{0}, 2, false},
+		{"GET 4xx", getMarkets, `[]`, []int{400}, 1, true},
+		{"GET gives up", getMarkets, `[]`, []int{500, 500, 500, 500}, 3, true},
+		{"DELETE 5xx", cancel, `{"id":"111"}`, []int{503}, 2, false},
+		{"POST without clientId", createOrder(0), `{"id":"111"}`, []int{500}, 1, true},
+		{"POST network error without clientId", createOrder(0), `{"id":"111"}`, []int{0}, 1, true},
+		{"POST with clientId", createOrder(42), `{"id":"111"}`, []int{500, 0}, 3, false},
+		{"POST with clientId 4xx", createOrder(42), `{"id":"111"}`, []int{400}, 1, true},
+		{"withdrawal", withdraw, `{"id":1}`, []int{500}, 1, true},
+		{"withdrawal 429", withdraw, `{"id":1}`, []int{429}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, headers := flakyServer(t, tt.body, tt.statuses...)
+			err := tt.call(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if n := len(headers()); n != tt.attempts {
+				t.Fatalf("sent %d requests, want %d", n, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryResigns(t *testing.T) {
+	c, headers := flakyServer(t, `[]`, 500, 500)
+	var (
+		mu  sync.Mutex
+		now = time.UnixMilli(testTimestamp)
+	)
+	signer, err := NewSigner(testAPIKey, testSecret, WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(time.Second)
+		return now
+	}))
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	c.Signer = signer
+	if _, err := c.GetTokenOpenAllOrders(context.Background(), "SOL_USDC"); err != nil {
+		t.Fatalf("GetTokenOpenAllOrders: %v", err)
+	}
+	hs := headers()
+	if len(hs) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(hs))
+	}
+	seen := make(map[string]bool)
+	for i, h := range hs {
+		ts, sig := h.Get("X-Timestamp"), h.Get("X-Signature")
+		if ts == "" || sig == "" || seen[ts] || seen[sig] {
+			t.Errorf("attempt %d reused or missing signature: %s %s", i+1, ts, sig)
+		}
+		seen[ts], seen[sig] = true, true
+	}
+}
+
+func TestRetryWaitsRetryAfter(t *testing.T) {
+	rateLimited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}
+	if delay, ok := fastRetry.Retry(1, rateLimited); !ok || delay != 3*time.Second {
+		t.Errorf("Retry = %v %v, want 3s", delay, ok)
+	}
+
+	// 客户端按 Retry-After 等待后重试
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0.2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	c.Retry = fastRetry
+	start := time.Now()
+	if _, err := c.GetMarkets(context.Background()); err != nil {
+		t.Fatalf("GetMarkets: %v", err)
+	}
+	if elapsed := time.Since(start); calls != 2 || elapsed < 200*time.Millisecond {
+		t.Errorf("calls = %d after %v, want 2 after at least 200ms", calls, elapsed)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	serverErr := &APIError{StatusCode: http.StatusInternalServerError}
+	for attempt, want := range []time.Duration{100, 200, 300, 300} {
+		delay, ok := b.Retry(attempt+1, serverErr)
+		if !ok || delay != want*time.Millisecond {
+			t.Errorf("attempt %d: Retry = %v %v, want %v", attempt+1, delay, ok, want*time.Millisecond)
+		}
+	}
+	if _, ok := b.Retry(5, serverErr); ok {
+		t.Error("Retry after MaxAttempts: want false")
+	}
+	if _, ok := b.Retry(1, &APIError{StatusCode: http.StatusBadRequest}); ok {
+		t.Error("Retry on 400: want false")
+	}
+	if _, ok := b.Retry(1, errors.New("decode error")); ok {
+		t.Error("Retry on non-transport error: want false")
+	}
+
+	jittered := ExponentialBackoff{MaxAttempts: 2, BaseDelay: 100 * time.Millisecond, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if delay, _ := jittered.Retry(1, serverErr); delay < 80*time.Millisecond || delay > 120*time.Millisecond {
+			t.Fatalf("jittered delay %v outside ±20%%", delay)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package backpack_interface
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWindow 为签名默认的有效时间窗口
+const DefaultWindow = 10 * time.Second
+
+// MaxWindow 为交易所允许的最大时间窗口
+const MaxWindow = 60 * time.Second
+
+// Signer 使用 ED25519 私钥为请求签名，REST 和 WebSocket 共用。
+// 私钥在创建时解析一次，可以并发使用。
+type Signer struct {
+	apiKey     string
+	privateKey ed25519.PrivateKey
+	window     time.Duration
+
+	mu    sync.RWMutex
+	clock func() time.Time
+}
+
+// SignerOption 为 Signer 的可选配置
+type SignerOption func(*Signer)
+
+// WithWindow 设置签名的有效时间窗口，默认 DefaultWindow
+func WithWindow(window time.Duration) SignerOption {
+	return func(s *Signer) {
+		s.window = window
+	}
+}
+
+// WithClock 设置生成时间戳的时钟，默认 time.Now
+func WithClock(clock func() time.Time) SignerOption {
+	return func(s *Signer) {
+		s.clock = clock
+	}
+}
+
+// NewSigner 解析 base64 编码的私钥并创建 Signer。
+// secret 可以是 32 字节的种子或 64 字节的完整私钥。
+func NewSigner(apiKey, secret string, opts ...SignerOption) (*Signer, error) {
+	if apiKey == "" {
+		return nil, errors.New("backpack: empty api key")
+	}
+	keyBytes, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("backpack: decode secret: %w", err)
+	}
+
+	var privateKey ed25519.PrivateKey
+	switch len(keyBytes) {
+	case ed25519.SeedSize:
+		privateKey = ed25519.NewKeyFromSeed(keyBytes)
+	case ed25519.PrivateKeySize:
+		privateKey = ed25519.PrivateKey(keyBytes)
+	default:
+		return nil, fmt.Errorf("backpack: invalid secret length %d, want %d or %d bytes",
+			len(keyBytes), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+
+	s := &Signer{
+		apiKey:     apiKey,
+		privateKey: privateKey,
+		window:     DefaultWindow,
+		clock:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.window < time.Millisecond || s.window > MaxWindow {
+		return nil, fmt.Errorf("backpack: invalid window %s, must be between 1ms and %s", s.window, MaxWindow)
+	}
+	if s.clock == nil {
+		return nil, errors.New("backpack: nil clock")
+	}
+	return s, nil
+}
+
+// SetClock 替换生成时间戳的时钟，例如接入 ClockSync.Now；clock 为空时忽略
+func (s *Signer) SetClock(clock func() time.Time) {
+	if clock == nil {
+		return
+	}
+	s.mu.Lock()
+	s.clock = clock
+	s.mu.Unlock()
+}
+
+// APIKey 返回签名使用的 API key
+func (s *Signer) APIKey() string {
+	return s.apiKey
+}
+
+// PublicKey 返回私钥对应的公钥
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Signature 为一次签名的结果
+type Signature struct {
+	APIKey    string
+	Signature string // base64 编码的签名
+	Timestamp int64  // 毫秒时间戳
+	Window    int64  // 毫秒
+}
+
+// Headers 返回 REST 请求需要的签名请求头
+func (sig Signature) Headers() map[string]string {
+	return map[string]string{
+		"X-API-Key":   sig.APIKey,
+		"X-Signature": sig.Signature,
+		"X-Timestamp": strconv.FormatInt(sig.Timestamp, 10),
+		"X-Window":    strconv.FormatInt(sig.Window, 10),
+	}
+}
+
+// WebSocket 返回订阅私有流时的 signature 数组：[apiKey, signature, timestamp, window]
+func (sig Signature) WebSocket() []string {
+	return []string{
+		sig.APIKey,
+		sig.Signature,
+		strconv.FormatInt(sig.Timestamp, 10),
+		strconv.FormatInt(sig.Window, 10),
+	}
+}
+
+// Instruction 为签名串中的一条指令及其参数
+type Instruction struct {
+	Name   string
+	Params map[string]interface{}
+}
+
+// Sign 为单条指令签名
+func (s *Signer) Sign(instruction string, params map[string]interface{}) Signature {
+	return s.sign([]Instruction{{Name: instruction, Params: params}})
+}
+
+// SignBatch 用一个签名覆盖多条指令，例如批量下单时每个订单各带一个 instruction=orderExecute。
+// 指令按传入顺序拼接。
+func (s *Signer) SignBatch(instructions []Instruction) (Signature, error) {
+	if len(instructions) == 0 {
+		return Signature{}, errors.New("backpack: no instructions to sign")
+	}
+	for _, ins := range instructions {
+		if ins.Name == "" {
+			return Signature{}, errors.New("backpack: empty instruction name")
+		}
+	}
+	return s.sign(instructions), nil
+}
+
+func (s *Signer) sign(instructions []Instruction) Signature {
+	s.mu.RLock()
+	clock := s.clock
+	s.mu.RUnlock()
+
+	timestamp := clock().UnixMilli()
+	window := s.window.Milliseconds()
+	msg := signString(instructions, timestamp, window)
+	return Signature{
+		APIKey:    s.apiKey,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, []byte(msg))),
+		Timestamp: timestamp,
+		Window:    window,
+	}
+}
+
+// signString 生成待签名的字符串，每条指令为
+// instruction=<指令>&<按键排序的参数>，多条指令用 & 连接，最后追加
+// &timestamp=<毫秒>&window=<毫秒>
+func signString(instructions []Instruction, timestamp, window int64) string {
+	var b strings.Builder
+	for i, ins := range instructions {
+		if i > 0 {
+			b.WriteString("&")
+		}
+		b.WriteString("instruction=")
+		b.WriteString(ins.Name)
+
+		values := convertMap(ins.Params)
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString("&")
+			b.WriteString(k)
+			b.WriteString("=")
+			b.WriteString(values[k])
+		}
+	}
+
+	b.WriteString("&timestamp=")
+	b.WriteString(strconv.FormatInt(timestamp, 10))
+	b.WriteString("&window=")
+	b.WriteString(strconv.FormatInt(window, 10))
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package backpack_interface
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 测试私钥为字节 0x00..0x1f 组成的种子，签名由 openssl pkeyutl 独立生成
+const (
+	testAPIKey    = "A6EHv/POEL4dcN0Y50vAmWfk1jCbpQ1fHdyGZBJVMbg="
+	testSecret    = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	testTimestamp = 1700000000000
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+	s, err := NewSigner(testAPIKey, testSecret,
+		WithWindow(5*time.Second),
+		WithClock(func() time.Time { return time.UnixMilli(testTimestamp) }),
+	)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return s
+}
+
+func TestSignString(t *testing.T) {
+	tests := []struct {
+		name        string
+		instruction string
+		params      map[string]interface{}
+		want        string
+	}{
+		{
+			name:        "no params",
+			instruction: "balanceQuery",
+			want:        "instruction=balanceQuery&timestamp=1700000000000&window=5000",
+		},
+		{
+			name:        "sorted params",
+			instruction: "orderExecute",
+			params: map[string]interface{}{
+				"symbol":      "SOL_USDC",
+				"side":        "Bid",
+				"orderType":   "Limit",
+				"price":       MustDecimal("0.10"),
+				"quantity":    MustDecimal("3"),
+				"postOnly":    true,
+				"timeInForce": "GTC",
+				"clientId":    42,
+			},
+			want: "instruction=orderExecute&clientId=42&orderType=Limit&postOnly=true&price=0.10" +
+				"&quantity=3&side=Bid&symbol=SOL_USDC&timeInForce=GTC&timestamp=1700000000000&window=5000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := signString([]Instruction{{Name: tt.instruction, Params: tt.params}}, testTimestamp, 5000)
+			if got != tt.want {
+				t.Errorf("signString() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignerGolden(t *testing.T) {
+	s := newTestSigner(t)
+
+	tests := []struct {
+		name        string
+		instruction string
+		params      map[string]interface{}
+		want        string
+	}{
+		{
+			name:        "balanceQuery",
+			instruction: "balanceQuery",
+			want:        "ogsyh1c6LnY10GiJaCoAEdHpkK2BT4KgS7NAd1PW2DQndw1OnR0WbY0M753KwW9Mq6Dmw3k+Kdw+wPxzKMCiAg==",
+		},
+		{
+			name:        "orderExecute",
+			instruction: "orderExecute",
+			params: map[string]interface{}{
+				"clientId":    42,
+				"orderType":   "Limit",
+				"postOnly":    true,
+				"price":       MustDecimal("0.10"),
+				"quantity":    MustDecimal("3"),
+				"side":        "Bid",
+				"symbol":      "SOL_USDC",
+				"timeInForce": "GTC",
+			},
+			want: "udor5Z6Vy+m1MigLns2susqonT54ScyfyiJkRkXqZ0BYvSUVOre8I9fAWRm0/G0p/no7ABBJVxiIHUr9kUjqDg==",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := s.Sign(tt.instruction, tt.params)
+			if sig.Signature != tt.want {
+				t.Errorf("Signature = %s, want %s", sig.Signature, tt.want)
+			}
+			wantHeaders := map[string]string{
+				"X-API-Key":   testAPIKey,
+				"X-Signature": tt.want,
+				"X-Timestamp": "1700000000000",
+				"X-Window":    "5000",
+			}
+			if got := sig.Headers(); !reflect.DeepEqual(got, wantHeaders) {
+				t.Errorf("Headers() = %v, want %v", got, wantHeaders)
+			}
+			wantWS := []string{testAPIKey, tt.want, "1700000000000", "5000"}
+			if got := sig.WebSocket(); !reflect.DeepEqual(got, wantWS) {
+				t.Errorf("WebSocket() = %v, want %v", got, wantWS)
+			}
+		})
+	}
+}
+
+func batchOrders() []Instruction {
+	return []Instruction{
+		{Name: "orderExecute", Params: map[string]interface{}{
+			"symbol":      "SOL_USDC",
+			"side":        "Bid",
+			"orderType":   "Limit",
+			"price":       MustDecimal("141"),
+			"quantity":    MustDecimal("12"),
+			"timeInForce": "GTC",
+		}},
+		{Name: "orderExecute", Params: map[string]interface{}{
+			"symbol":      "SOL_USDC",
+			"side":        "Ask",
+			"orderType":   "Limit",
+			"price":       MustDecimal("142"),
+			"quantity":    MustDecimal("5"),
+			"timeInForce": "GTC",
+		}},
+	}
+}
+
+func TestSignStringBatch(t *testing.T) {
+	want := "instruction=orderExecute&orderType=Limit&price=141&quantity=12&side=Bid&symbol=SOL_USDC&timeInForce=GTC" +
+		"&instruction=orderExecute&orderType=Limit&price=142&quantity=5&side=Ask&symbol=SOL_USDC&timeInForce=GTC" +
+		"&timestamp=1700000000000&window=5000"
+	if got := signString(batchOrders(), testTimestamp, 5000); got != want {
+		t.Errorf("signString() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSignerBatchGolden(t *testing.T) {
+	s := newTestSigner(t)
+	sig, err := s.SignBatch(batchOrders())
+	if err != nil {
+		t.Fatalf("SignBatch: %v", err)
+	}
+	want := "BdsbKStbx5fuuwnkq6faeDDMj7DyLB9ZNsYwyk3nzaWWZb102IL/ZzlUxU3aCo6bAOwwx44cARYCiLe++GpzDQ=="
+	if sig.Signature != want {
+		t.Errorf("Signature = %s, want %s", sig.Signature, want)
+	}
+
+	if _, err := s.SignBatch(nil); err == nil {
+		t.Error("SignBatch(nil) error = nil, want error")
+	}
+	if _, err := s.SignBatch([]Instruction{{}}); err == nil {
+		t.Error("SignBatch(empty name) error = nil, want error")
+	}
+}
+
+func TestSignerPublicKey(t *testing.T) {
+	s := newTestSigner(t)
+	if got := base64.StdEncoding.EncodeToString(s.PublicKey()); got != testAPIKey {
+		t.Errorf("PublicKey() = %s, want %s", got, testAPIKey)
+	}
+}
+
+func TestNewSignerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		secret string
+		opts   []SignerOption
+	}{
+		{"empty api key", "", testSecret, nil},
+		{"bad base64", testAPIKey, "not base64!", nil},
+		{"short key", testAPIKey, base64.StdEncoding.EncodeToString(make([]byte, 16)), nil},
+		{"zero window", testAPIKey, testSecret, []SignerOption{WithWindow(0)}},
+		{"window too large", testAPIKey, testSecret, []SignerOption{WithWindow(2 * MaxWindow)}},
+		{"nil clock", testAPIKey, testSecret, []SignerOption{WithClock(nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner(tt.apiKey, tt.secret, tt.opts...); err == nil {
+				t.Error("NewSigner() error = nil, want error")
+			}
+		})
+	}
+}
@@ -1,214 +1,545 @@
-package main // 声明 main 包，表明当前是一个可执行程序
+// Package backpack_websocket 为 Backpack 交易所 WebSocket 行情和账户推送的客户端
+package backpack_websocket
 
 import (
-	"crypto/ed25519"
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"os/signal"
-	"sort"
-	"strings"
+	"math/rand"
+	"sync"
 	"time"
 	"websocket-main"
-)
 
-var (
-	interrupt = make(chan os.Signal, 1)
-	done      = make(chan struct{})
+	"backpack_api/backpack_interface"
 )
 
 // WebSocket 连接细节
 const (
-	wsURL    = "wss://ws.backpack.exchange"
-	readWait = 3 * time.Second
+	DefaultURL = "wss://ws.backpack.exchange"
+	writeWait  = 3 * time.Second
+)
+
+// 默认的重连退避时间
+const (
+	DefaultReconnectMin = 500 * time.Millisecond
+	DefaultReconnectMax = 30 * time.Second
 )
 
+// ErrClosed 表示客户端已经调用过 Close
+var ErrClosed = errors.New("backpack websocket: client closed")
+
 // OrderUpdate表示订单更新事件的结构
 type OrderUpdate struct {
-	Event         string `json:"e"` // Event type
-	EventTime     int64  `json:"E"` // Event time in microseconds
-	Symbol        string `json:"s"` // Symbol
-	ClientOrderID string `json:"c"` // Client order ID
-	Side          string `json:"S"` // Side
-	OrderType     string `json:"o"` // Order type
-	TimeInForce   string `json:"f"` // Time in force
-	Quantity      string `json:"q"` // Quantity
-	QuoteQuantity string `json:"Q"` // Quantity in quote
-	Price         string `json:"p"` // Price
-	TriggerPrice  string `json:"P"` // Trigger price
-	OrderState    string `json:"X"` // Order state
-	OrderID       string `json:"i"` // Order ID
-	TradeID       string `json:"t"` // Trade id
-	FillQuantity  string `json:"l"` // Fill quantity
-	ExecutedQty   string `json:"z"` // Executed quantity
-	ExecutedQtyQ  string `json:"Z"` // Executed quantity in quote
-	FillPrice     string `json:"L"` // Fill price
-	IsMaker       bool   `json:"m"` // Whether the order was maker
-	Fee           string `json:"n"` // Fee
-	FeeSymbol     string `json:"N"` // Fee symbol
-	SelfTradePrev string `json:"V"` // Self trade prevention
-	EngineTime    int64  `json:"T"` // Engine timestamp in microseconds
-}
-
-// WebSocketClient表示WebSocket客户端
-type WebSocketClient struct {
-	conn *websocket.Conn
+	Event         string                                 `json:"e"` // Event type
+	EventTime     int64                                  `json:"E"` // Event time in microseconds
+	Symbol        string                                 `json:"s"` // Symbol
+	ClientOrderID string                                 `json:"c"` // Client order ID
+	Side          backpack_interface.Side                `json:"S"` // Side
+	OrderType     backpack_interface.OrderType           `json:"o"` // Order type
+	TimeInForce   backpack_interface.TimeInForce         `json:"f"` // Time in force
+	Quantity      backpack_interface.Decimal             `json:"q"` // Quantity
+	QuoteQuantity backpack_interface.Decimal             `json:"Q"` // Quantity in quote
+	Price         backpack_interface.Decimal             `json:"p"` // Price
+	TriggerPrice  backpack_interface.Decimal             `json:"P"` // Trigger price
+	OrderState    backpack_interface.OrderStatus         `json:"X"` // Order state
+	OrderID       string                                 `json:"i"` // Order ID
+	TradeID       string                                 `json:"t"` // Trade id
+	FillQuantity  backpack_interface.Decimal             `json:"l"` // Fill quantity
+	ExecutedQty   backpack_interface.Decimal             `json:"z"` // Executed quantity
+	ExecutedQtyQ  backpack_interface.Decimal             `json:"Z"` // Executed quantity in quote
+	FillPrice     backpack_interface.Decimal             `json:"L"` // Fill price
+	IsMaker       bool                                   `json:"m"` // Whether the order was maker
+	Fee           backpack_interface.Decimal             `json:"n"` // Fee
+	FeeSymbol     string                                 `json:"N"` // Fee symbol
+	SelfTradePrev backpack_interface.SelfTradePrevention `json:"V"` // Self trade prevention
+	EngineTime    int64                                  `json:"T"` // Engine timestamp in microseconds
 }
 
-// NewWebSocketClient创建新的WebSocketClient实例
-func NewWebSocketClient() (*WebSocketClient, error) {
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-	if err != nil {
-		panic(err)
+// ConnState 为连接状态
+type ConnState int
+
+const (
+	StateConnecting   ConnState = iota // 首次拨号
+	StateConnected                     // 已连接并完成订阅重放
+	StateReconnecting                  // 断线后等待重连
+	StateClosed                        // ListenAndServe 已退出
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
 	}
+	return fmt.Sprintf("ConnState(%d)", int(s))
+}
 
-	return &WebSocketClient{conn: conn}, nil
+// StateEvent 为连接状态变化事件
+type StateEvent struct {
+	State   ConnState
+	Attempt int   // 连续失败的次数，连接成功后归零
+	Err     error // 导致断线或拨号失败的错误
 }
 
-// Subscribe订阅一个流
-func (client *WebSocketClient) Subscribe(stream string) error {
-	data := struct {
-		Method string   `json:"method"`
-		Params []string `json:"params"`
-	}{
-		Method: "SUBSCRIBE",
-		Params: []string{stream},
+// Option 为 WebSocketClient 的可选配置
+type Option func(*WebSocketClient)
+
+// WithURL 设置 WebSocket 地址，默认 DefaultURL
+func WithURL(url string) Option {
+	return func(c *WebSocketClient) { c.url = url }
+}
+
+// WithReconnectBackoff 设置重连的最短和最长等待时间
+func WithReconnectBackoff(min, max time.Duration) Option {
+	return func(c *WebSocketClient) {
+		c.reconnectMin = min
+		c.reconnectMax = max
 	}
+}
+
+// WithStateHandler 设置连接状态变化的回调，回调在读循环中同步执行，不应阻塞
+func WithStateHandler(fn func(StateEvent)) Option {
+	return func(c *WebSocketClient) { c.onState = fn }
+}
+
+// WithSigner 设置账户签名器，SubscribePrivate 需要
+func WithSigner(signer *backpack_interface.Signer) Option {
+	return func(c *WebSocketClient) { c.signer = signer }
+}
+
+// WithErrorHandler 设置消息解析错误的回调，默认忽略
+func WithErrorHandler(fn func(error)) Option {
+	return func(c *WebSocketClient) { c.onError = fn }
+}
 
-	return client.sendJSON(data)
+// WithMessageHandler 设置原始消息的回调，回调在读循环中同步执行，不应阻塞
+func WithMessageHandler(fn func(message []byte)) Option {
+	return func(c *WebSocketClient) { c.onMessage = fn }
+}
+
+// WebSocketClient表示WebSocket客户端。
+// ListenAndServe 负责拨号、断线重连，并在每次连接后重放所有有效的订阅。
+// 收到的消息交给内嵌的 Dispatcher，用 OnDepth、OnTrade 等方法注册处理函数。
+type WebSocketClient struct {
+	*Dispatcher
+
+	url          string
+	dialer       *websocket.Dialer
+	reconnectMin time.Duration
+	reconnectMax time.Duration
+	onState      func(StateEvent)
+	onMessage    func(message []byte)
+	onError      func(error)
+	signer       *backpack_interface.Signer
+	states       chan StateEvent
+
+	pingInterval   time.Duration
+	readTimeout    time.Duration
+	staleThreshold time.Duration
+	onStaleStream  func(stream string, age time.Duration)
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	subs     []string             // 有效的订阅，按订阅顺序重放
+	private  map[string]bool      // 需要签名的订阅
+	lastSeen map[string]time.Time // 每个流最近一次收到消息的时间
+	stats    ClientStats
+	started  bool  // ListenAndServe 已经运行
+	closed   bool  // Close 已经调用
+	err      error // ListenAndServe 退出的原因
+
+	closing   chan struct{} // Close 超时后关闭，强制 ListenAndServe 退出
+	closeOnce sync.Once
+	done      chan struct{} // ListenAndServe 退出后关闭
+
+	writeMu sync.Mutex // gorilla/websocket 不支持并发写
 }
 
-// Unsubscribe表示取消订阅某个流
-func (client *WebSocketClient) Unsubscribe(stream string) error {
-	data := struct {
-		Method string   `json:"method"`
-		Params []string `json:"params"`
-	}{
-		Method: "UNSUBSCRIBE",
-		Params: []string{stream},
+// NewWebSocketClient创建新的WebSocketClient实例，连接在 ListenAndServe 中建立
+func NewWebSocketClient(opts ...Option) *WebSocketClient {
+	c := &WebSocketClient{
+		Dispatcher:   NewDispatcher(),
+		url:          DefaultURL,
+		dialer:       websocket.DefaultDialer,
+		reconnectMin: DefaultReconnectMin,
+		reconnectMax: DefaultReconnectMax,
+		states:       make(chan StateEvent, 16),
+		pingInterval: DefaultPingInterval,
+		readTimeout:  DefaultReadTimeout,
+		private:      make(map[string]bool),
+		lastSeen:     make(map[string]time.Time),
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
 
-	return client.sendJSON(data)
+// States 返回连接状态事件的通道。通道满时丢弃最新的事件，不会阻塞读循环。
+func (client *WebSocketClient) States() <-chan StateEvent {
+	return client.states
 }
 
-// sendJSON通过WebSocket连接发送JSON消息
-func (client *WebSocketClient) sendJSON(data interface{}) error {
-	client.conn.SetWriteDeadline(time.Now().Add(readWait))
-	return client.conn.WriteJSON(data)
+// Subscriptions 返回当前有效的订阅
+func (client *WebSocketClient) Subscriptions() []string {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return append([]string(nil), client.subs...)
 }
 
-// ListenAndServe监听传入的WebSocket消息
-func (client *WebSocketClient) ListenAndServe() {
-	defer client.conn.Close()
+// Subscribe订阅一个或多个流，多个流在同一条 SUBSCRIBE 消息中发送。
+// 订阅会被记住并在重连后重放；未连接时只记录订阅，连接建立后发送。
+func (client *WebSocketClient) Subscribe(ctx context.Context, streams ...string) error {
+	return client.subscribe(ctx, streams, false)
+}
 
-	for {
-		select {
-		case <-done:
-			return
-		default:
-			_, message, err := client.conn.ReadMessage()
-			if err != nil {
-				panic(err)
-			}
+// SubscribePrivate订阅需要认证的流，例如 account.orderUpdate。
+// 每次发送（包括重连后的重放）都会用账户密钥重新签名 subscribe 指令。
+func (client *WebSocketClient) SubscribePrivate(ctx context.Context, streams ...string) error {
+	if client.signer == nil {
+		return backpack_interface.ErrMissingCredentials
+	}
+	return client.subscribe(ctx, streams, true)
+}
 
-			log.Printf("Received message: %s\n", message)
+func (client *WebSocketClient) subscribe(ctx context.Context, streams []string, private bool) error {
+	if len(streams) == 0 {
+		return nil
+	}
+	client.mu.Lock()
+	if client.closed {
+		client.mu.Unlock()
+		return ErrClosed
+	}
+	for _, stream := range streams {
+		if !contains(client.subs, stream) {
+			client.subs = append(client.subs, stream)
+			client.lastSeen[stream] = time.Now()
 		}
+		client.private[stream] = private
 	}
+	conn := client.conn
+	client.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return client.sendJSON(ctx, conn, client.subscribeMessage(streams, private))
 }
 
-// 生成签名
-func generateSignature(
-	instruction,
-	apikey,
-	secret string,
-	payload map[string]string,
-) map[string]string {
-	timestamp := fmt.Sprintf("%d", time.Now().UnixNano()/int64(time.Millisecond))
-	window := "10000"
+// Unsubscribe表示取消订阅一个或多个流
+func (client *WebSocketClient) Unsubscribe(ctx context.Context, streams ...string) error {
+	if len(streams) == 0 {
+		return nil
+	}
+	client.mu.Lock()
+	for _, stream := range streams {
+		for i, s := range client.subs {
+			if s == stream {
+				client.subs = append(client.subs[:i], client.subs[i+1:]...)
+				break
+			}
+		}
+		delete(client.private, stream)
+		delete(client.lastSeen, stream)
+	}
+	conn := client.conn
+	client.mu.Unlock()
 
-	data := make(map[string]string)
-	data["instruction"] = instruction
-	for k, v := range payload {
-		data[k] = v
+	if conn == nil {
+		return nil
 	}
-	data["timestamp"] = timestamp
-	data["window"] = window
+	return client.sendJSON(ctx, conn, subscription{Method: "UNSUBSCRIBE", Params: streams})
+}
+
+type subscription struct {
+	Method    string   `json:"method"`
+	Params    []string `json:"params"`
+	Signature []string `json:"signature,omitempty"`
+}
 
-	// Sort data by keys
-	keys := make([]string, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
+// subscribeMessage 构造 SUBSCRIBE 消息，私有流附带新的签名
+func (client *WebSocketClient) subscribeMessage(streams []string, private bool) subscription {
+	msg := subscription{Method: "SUBSCRIBE", Params: streams}
+	if private {
+		msg.Signature = client.signer.Sign("subscribe", nil).WebSocket()
 	}
-	sort.Strings(keys)
+	return msg
+}
 
-	var sortedData []string
-	for _, k := range keys {
-		sortedData = append(sortedData, k+"="+data[k])
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
 	}
+	return false
+}
 
-	// Create a string to sign
-	signString := strings.Join(sortedData, "&")
+// sendJSON通过WebSocket连接发送JSON消息，ctx 的截止时间早于 writeWait 时以 ctx 为准
+func (client *WebSocketClient) sendJSON(ctx context.Context, conn *websocket.Conn, data interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(writeWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	conn.SetWriteDeadline(deadline)
+	return conn.WriteJSON(data)
+}
 
-	// Sign the string using the private key
-	privateKeyBytes, err := base64.StdEncoding.DecodeString(secret)
-	if err != nil {
-		panic(err)
+// ListenAndServe 建立连接并读取消息，断线后按指数退避重连并重放订阅。
+// ctx 取消或 Close 后返回：ctx 取消时返回 nil，Close 时返回关闭握手的错误。
+// 每个客户端只能运行一次，Close 之后调用返回 ErrClosed。
+func (client *WebSocketClient) ListenAndServe(ctx context.Context) error {
+	client.mu.Lock()
+	if client.closed || client.started {
+		client.mu.Unlock()
+		return ErrClosed
 	}
-	privateKey := ed25519.PrivateKey(privateKeyBytes)
-	signature := ed25519.Sign(privateKey, []byte(signString))
+	client.started = true
+	client.mu.Unlock()
 
-	// Encode the signature in base64
-	signatureB64 := base64.StdEncoding.EncodeToString(signature)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-client.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := client.listen(ctx)
+	client.mu.Lock()
+	client.err = err
+	client.mu.Unlock()
+	close(client.done)
+	client.emit(StateEvent{State: StateClosed, Err: err})
+	return err
+}
 
-	head := map[string]string{
-		"X-API-Key":   apikey,
-		"X-Signature": signatureB64,
-		"X-Timestamp": timestamp,
-		"X-Window":    window,
-	}
+func (client *WebSocketClient) listen(ctx context.Context) error {
+	attempt := 0
+	client.emit(StateEvent{State: StateConnecting})
+	for {
+		err := client.serve(ctx, func() { attempt = 0 })
+		if client.isClosed() {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
 
-	return head
+		attempt++
+		client.mu.Lock()
+		client.stats.Reconnects++
+		client.mu.Unlock()
+		client.emit(StateEvent{State: StateReconnecting, Attempt: attempt, Err: err})
+		timer := time.NewTimer(client.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
 }
 
-func handleMessage(message []byte, callback func(orderUpdate OrderUpdate)) {
-	var orderUpdate OrderUpdate
-	err := json.Unmarshal(message, &orderUpdate)
+// serve 完成一次连接的生命周期：拨号、重放订阅、读消息直到出错
+func (client *WebSocketClient) serve(ctx context.Context, connected func()) error {
+	conn, _, err := client.dialer.DialContext(ctx, client.url, nil)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer conn.Close()
+
+	client.mu.Lock()
+	client.conn = conn
+	var public, private []string
+	for _, stream := range client.subs {
+		if client.private[stream] {
+			private = append(private, stream)
+		} else {
+			public = append(public, stream)
+		}
+	}
+	client.mu.Unlock()
+	defer func() {
+		client.mu.Lock()
+		client.conn = nil
+		client.mu.Unlock()
+	}()
+
+	// 公开流和私有流各用一条消息重放
+	if len(public) > 0 {
+		if err := client.sendJSON(ctx, conn, client.subscribeMessage(public, false)); err != nil {
+			return fmt.Errorf("resubscribe: %w", err)
+		}
+	}
+	if len(private) > 0 {
+		if err := client.sendJSON(ctx, conn, client.subscribeMessage(private, true)); err != nil {
+			return fmt.Errorf("resubscribe private: %w", err)
+		}
+	}
+	connected()
+	client.emit(StateEvent{State: StateConnected})
+
+	// ReadMessage 会阻塞，ctx 取消时关闭连接使其返回；
+	// 读超时时间内没有任何数据时 ReadMessage 返回超时错误并触发重连
+	client.setupHeartbeat(conn)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(stop)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		client.heartbeat(conn, stop)
+	}()
 
-	// Process the order update event
-	callback(orderUpdate)
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return client.readError(err)
+		}
+		client.extendReadDeadline(conn)
+		client.handleMessage(message)
+	}
 }
 
-// processOrderUpdate处理订单更新事件
-func processOrderUpdate(orderUpdate OrderUpdate) {
-	// Print or process the order update event as needed
-	fmt.Printf("Received order update event: %+v\n", orderUpdate)
+// Close 优雅关闭客户端：取消所有订阅，发送关闭帧，等待读协程退出并返回 ListenAndServe 的退出原因。
+// ctx 到期时强制关闭连接并返回 ctx 的错误。
+func (client *WebSocketClient) Close(ctx context.Context) error {
+	client.mu.Lock()
+	alreadyClosed := client.closed
+	client.closed = true
+	started := client.started
+	conn := client.conn
+	subs := client.subs
+	client.subs = nil
+	client.mu.Unlock()
+
+	if !started {
+		client.stop()
+		return nil
+	}
+	if alreadyClosed {
+		return client.wait(ctx)
+	}
+
+	if conn == nil {
+		// 正在等待重连，没有连接可以握手
+		client.stop()
+		return client.wait(ctx)
+	}
+
+	var errs []error
+	if len(subs) > 0 {
+		if err := client.sendJSON(ctx, conn, subscription{Method: "UNSUBSCRIBE", Params: subs}); err != nil {
+			errs = append(errs, fmt.Errorf("unsubscribe: %w", err))
+		}
+	}
+	deadline := time.Now().Add(writeWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := conn.WriteControl(websocket.CloseMessage, msg, deadline); err != nil {
+		// 关闭帧发不出去时直接断开
+		errs = append(errs, fmt.Errorf("close frame: %w", err))
+		client.stop()
+	}
+	errs = append(errs, client.wait(ctx))
+	return errors.Join(errs...)
+}
+
+// wait 等待 ListenAndServe 退出，ctx 到期时强制关闭
+func (client *WebSocketClient) wait(ctx context.Context) error {
+	select {
+	case <-client.done:
+	case <-ctx.Done():
+		client.stop()
+		<-client.done
+		return ctx.Err()
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.err
 }
 
-func main() {
-	// 处理中断信号
-	signal.Notify(interrupt, os.Interrupt)
-	defer close(done)
+func (client *WebSocketClient) stop() {
+	client.closeOnce.Do(func() { close(client.closing) })
+}
 
-	// 创建WebSocket客户端
-	client, err := NewWebSocketClient()
-	if err != nil {
-		panic(err)
+func (client *WebSocketClient) isClosed() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.closed
+}
+
+// backoff 返回第 attempt 次重连前的等待时间，带 ±20% 的随机抖动
+func (client *WebSocketClient) backoff(attempt int) time.Duration {
+	delay := client.reconnectMin
+	for i := 1; i < attempt && delay < client.reconnectMax; i++ {
+		delay *= 2
+	}
+	if delay > client.reconnectMax {
+		delay = client.reconnectMax
 	}
+	return delay + time.Duration((rand.Float64()*0.4-0.2)*float64(delay))
+}
 
-	// 订阅流
-	if err := client.Subscribe("depth.SOL_USDC"); err != nil {
-		panic(err)
+// emit 发出连接状态事件
+func (client *WebSocketClient) emit(ev StateEvent) {
+	if client.onState != nil {
+		client.onState(ev)
+	}
+	select {
+	case client.states <- ev:
+	default:
 	}
+}
 
-	// 监听传入的消息
-	go client.ListenAndServe()
+// handleMessage 记录流的活跃时间，把原始消息交给 onMessage 和 Dispatcher
+func (client *WebSocketClient) handleMessage(message []byte) {
+	env, err := decodeEnvelope(message)
+	client.touch(env.Stream, time.Now())
+	if client.onMessage != nil {
+		client.onMessage(message)
+	}
+	if err == nil {
+		err = client.dispatch(env)
+	}
+	if err != nil {
+		client.reportError(err)
+	}
+}
 
-	// 等待中断信号
-	<-interrupt
-	log.Println("Received interrupt signal, closing WebSocket connection...")
+// reportError 把解析错误交给 onError
+func (client *WebSocketClient) reportError(err error) {
+	if client.onError != nil {
+		client.onError(err)
+	}
 }
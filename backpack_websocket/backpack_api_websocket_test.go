@@ -0,0 +1,398 @@
+package backpack_websocket
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"websocket-main"
+
+	"backpack_api/backpack_interface"
+)
+
+// 测试私钥为字节 0x00..0x1f 组成的种子
+const (
+	testAPIKey = "A6EHv/POEL4dcN0Y50vAmWfk1jCbpQ1fHdyGZBJVMbg="
+	testSecret = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+)
+
+// newTestServer 启动本地 WebSocket 服务器，返回其地址和接受的连接
+func newTestServer(t *testing.T) (string, <-chan *websocket.Conn) {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 8)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), conns
+}
+
+// accept 等待客户端建立下一个连接
+func accept(t *testing.T, conns <-chan *websocket.Conn) *websocket.Conn {
+	t.Helper()
+	select {
+	case conn := <-conns:
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not connect")
+		return nil
+	}
+}
+
+// expectMessage 读取客户端发来的下一条订阅消息
+func expectMessage(t *testing.T, conn *websocket.Conn) subscription {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg subscription
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read subscription: %v", err)
+	}
+	return msg
+}
+
+// expectSubscription 检查客户端发来的下一条消息的方法和流
+func expectSubscription(t *testing.T, conn *websocket.Conn, method string, streams ...string) subscription {
+	t.Helper()
+	msg := expectMessage(t, conn)
+	if msg.Method != method || !reflect.DeepEqual(msg.Params, streams) {
+		t.Fatalf("got %s %v, want %s %v", msg.Method, msg.Params, method, streams)
+	}
+	return msg
+}
+
+// waitState 等待客户端进入 state，返回对应的事件
+func waitState(t *testing.T, client *WebSocketClient, state ConnState) StateEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-client.States():
+			if ev.State == state {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("client did not reach state %s", state)
+		}
+	}
+}
+
+// serveClient 在后台运行 ListenAndServe，返回停止并等待其退出的函数，可重复调用
+func serveClient(t *testing.T, client *WebSocketClient) func() error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- client.ListenAndServe(ctx) }()
+	var (
+		once sync.Once
+		err  error
+	)
+	stop := func() error {
+		once.Do(func() {
+			cancel()
+			select {
+			case err = <-done:
+			case <-time.After(5 * time.Second):
+				t.Error("ListenAndServe did not return")
+			}
+		})
+		return err
+	}
+	t.Cleanup(func() { stop() })
+	return stop
+}
+
+func TestReconnectReplaysSubscriptions(t *testing.T) {
+	url, conns := newTestServer(t)
+	messages := make(chan string, 8)
+	client := NewWebSocketClient(WithURL(url), WithReconnectBackoff(10*time.Millisecond, 50*time.Millisecond),
+		WithMessageHandler(func(message []byte) { messages <- string(message) }))
+	ctx := context.Background()
+
+	// 未连接时只记录订阅
+	if err := client.Subscribe(ctx, "depth.SOL_USDC", "trade.SOL_USDC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := client.Subscribe(ctx, "depth.SOL_USDC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	stop := serveClient(t, client)
+
+	conn := accept(t, conns)
+	expectSubscription(t, conn, "SUBSCRIBE", "depth.SOL_USDC", "trade.SOL_USDC")
+	waitState(t, client, StateConnected)
+
+	// 已连接时立即发送，多个流在一条消息中
+	if err := client.Subscribe(ctx, "ticker.SOL_USDC", "bookTicker.SOL_USDC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	expectSubscription(t, conn, "SUBSCRIBE", "ticker.SOL_USDC", "bookTicker.SOL_USDC")
+	if err := client.Unsubscribe(ctx, "trade.SOL_USDC"); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	expectSubscription(t, conn, "UNSUBSCRIBE", "trade.SOL_USDC")
+
+	// 服务器断开后重连，只重放仍然有效的订阅
+	conn.Close()
+	if ev := waitState(t, client, StateReconnecting); ev.Attempt != 1 || ev.Err == nil {
+		t.Errorf("reconnecting event = %+v, want attempt 1 with error", ev)
+	}
+	conn = accept(t, conns)
+	expectSubscription(t, conn, "SUBSCRIBE", "depth.SOL_USDC", "ticker.SOL_USDC", "bookTicker.SOL_USDC")
+	waitState(t, client, StateConnected)
+	if got := client.Subscriptions(); !reflect.DeepEqual(got, []string{"depth.SOL_USDC", "ticker.SOL_USDC", "bookTicker.SOL_USDC"}) {
+		t.Errorf("Subscriptions = %v", got)
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"ticker.SOL_USDC","data":{}}`))
+	select {
+	case msg := <-messages:
+		if !strings.Contains(msg, "ticker.SOL_USDC") {
+			t.Errorf("message = %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not delivered")
+	}
+
+	if err := stop(); err != nil {
+		t.Errorf("ListenAndServe = %v, want nil after cancel", err)
+	}
+	waitState(t, client, StateClosed)
+}
+
+// verifySubscribeSignature 校验 signature 数组为 [apiKey, 签名, 时间戳, 窗口] 且签名有效，返回时间戳
+func verifySubscribeSignature(t *testing.T, signer *backpack_interface.Signer, signature []string) string {
+	t.Helper()
+	if len(signature) != 4 || signature[0] != testAPIKey {
+		t.Fatalf("signature = %v, want [apiKey, signature, timestamp, window]", signature)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature[1])
+	if err != nil {
+		t.Fatalf("decode signature: %v", err)
+	}
+	msg := "instruction=subscribe&timestamp=" + signature[2] + "&window=" + signature[3]
+	if !ed25519.Verify(signer.PublicKey(), []byte(msg), sig) {
+		t.Errorf("signature does not cover %s", msg)
+	}
+	return signature[2]
+}
+
+func TestSubscribePrivate(t *testing.T) {
+	if err := NewWebSocketClient().SubscribePrivate(context.Background(), "account.orderUpdate"); !errors.Is(err, backpack_interface.ErrMissingCredentials) {
+		t.Fatalf("SubscribePrivate without signer = %v, want ErrMissingCredentials", err)
+	}
+
+	var (
+		mu  sync.Mutex
+		now = time.UnixMilli(1700000000000)
+	)
+	signer, err := backpack_interface.NewSigner(testAPIKey, testSecret, backpack_interface.WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(time.Second)
+		return now
+	}))
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	url, conns := newTestServer(t)
+	client := NewWebSocketClient(WithURL(url), WithSigner(signer),
+		WithReconnectBackoff(10*time.Millisecond, 50*time.Millisecond))
+	ctx := context.Background()
+	if err := client.Subscribe(ctx, "depth.SOL_USDC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := client.SubscribePrivate(ctx, "account.orderUpdate"); err != nil {
+		t.Fatalf("SubscribePrivate: %v", err)
+	}
+	if err := client.Subscribe(ctx, "trade.SOL_USDC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	serveClient(t, client)
+
+	// 公开流和私有流分开发送，只有私有流带签名
+	conn := accept(t, conns)
+	if msg := expectSubscription(t, conn, "SUBSCRIBE", "depth.SOL_USDC", "trade.SOL_USDC"); msg.Signature != nil {
+		t.Errorf("public stream signature = %v, want none", msg.Signature)
+	}
+	msg := expectSubscription(t, conn, "SUBSCRIBE", "account.orderUpdate")
+	first := verifySubscribeSignature(t, signer, msg.Signature)
+	if msg.Signature[3] != "10000" {
+		t.Errorf("window = %s, want 10000", msg.Signature[3])
+	}
+	waitState(t, client, StateConnected)
+
+	// 已连接时订阅私有流立即签名发送
+	if err := client.SubscribePrivate(ctx, "account.positionUpdate"); err != nil {
+		t.Fatalf("SubscribePrivate: %v", err)
+	}
+	msg = expectSubscription(t, conn, "SUBSCRIBE", "account.positionUpdate")
+	second := verifySubscribeSignature(t, signer, msg.Signature)
+
+	// 重连后重放时重新签名
+	conn.Close()
+	conn = accept(t, conns)
+	expectSubscription(t, conn, "SUBSCRIBE", "depth.SOL_USDC", "trade.SOL_USDC")
+	msg = expectSubscription(t, conn, "SUBSCRIBE", "account.orderUpdate", "account.positionUpdate")
+	if replayed := verifySubscribeSignature(t, signer, msg.Signature); replayed == first || replayed == second {
+		t.Errorf("replayed signature reuses timestamp %s", replayed)
+	}
+}
+
+func TestOrderUpdateHandlers(t *testing.T) {
+	url, conns := newTestServer(t)
+	var errs []error
+	client := NewWebSocketClient(WithURL(url), WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	updates := make(chan OrderUpdate, 4)
+	client.OnOrderUpdate(func(u OrderUpdate) { updates <- u })
+	serveClient(t, client)
+
+	conn := accept(t, conns)
+	conn.WriteMessage(websocket.TextMessage, []byte(`not json`))
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"depth.SOL_USDC","data":{}}`))
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"account.orderUpdate","data":{"e":"orderFill","s":"SOL_USDC","i":"111"}}`))
+	select {
+	case u := <-updates:
+		if u.Event != "orderFill" || u.Symbol != "SOL_USDC" || u.OrderID != "111" {
+			t.Errorf("update = %+v", u)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("order update not delivered")
+	}
+	// 处理函数在读循环中同步执行，收到订单更新时前面的错误已经上报
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want one decode error", errs)
+	}
+}
+
+func TestReconnectAfterDialError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	client := NewWebSocketClient(WithURL("ws"+strings.TrimPrefix(srv.URL, "http")),
+		WithReconnectBackoff(10*time.Millisecond, 20*time.Millisecond))
+	serveClient(t, client)
+
+	// 拨号失败的次数累计，直到连接成功
+	for attempt := 1; attempt <= 3; attempt++ {
+		if ev := waitState(t, client, StateReconnecting); ev.Attempt != attempt || ev.Err == nil {
+			t.Fatalf("reconnecting event = %+v, want attempt %d with error", ev, attempt)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	url, conns := newTestServer(t)
+	client := NewWebSocketClient(WithURL(url))
+	ctx := context.Background()
+	client.Subscribe(ctx, "depth.SOL_USDC", "trade.SOL_USDC")
+	stop := serveClient(t, client)
+
+	conn := accept(t, conns)
+	expectSubscription(t, conn, "SUBSCRIBE", "depth.SOL_USDC", "trade.SOL_USDC")
+	waitState(t, client, StateConnected)
+
+	closed := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		closed <- client.Close(ctx)
+	}()
+
+	// 先取消所有订阅，再发送关闭帧；服务器读到关闭帧时自动回复
+	expectSubscription(t, conn, "UNSUBSCRIBE", "depth.SOL_USDC", "trade.SOL_USDC")
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("server read %v, want normal close frame", err)
+	}
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	// Close 返回时 ListenAndServe 已经退出
+	select {
+	case <-client.done:
+	default:
+		t.Error("Close returned before ListenAndServe exited")
+	}
+	if err := stop(); err != nil {
+		t.Errorf("ListenAndServe = %v, want nil", err)
+	}
+	waitState(t, client, StateClosed)
+
+	if err := client.Subscribe(ctx, "ticker.SOL_USDC"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close = %v, want ErrClosed", err)
+	}
+	if err := client.ListenAndServe(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("ListenAndServe after Close = %v, want ErrClosed", err)
+	}
+	if err := client.Close(ctx); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	url, conns := newTestServer(t)
+	client := NewWebSocketClient(WithURL(url))
+	stop := serveClient(t, client)
+	accept(t, conns)
+	waitState(t, client, StateConnected)
+
+	// 服务器不回复关闭帧，ctx 到期后强制断开
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close = %v, want DeadlineExceeded", err)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("ListenAndServe = %v, want nil", err)
+	}
+}
+
+func TestCloseBeforeListen(t *testing.T) {
+	client := NewWebSocketClient()
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	if err := client.ListenAndServe(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("ListenAndServe after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	client := NewWebSocketClient(WithReconnectBackoff(100*time.Millisecond, time.Second))
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			d := client.backoff(tt.attempt)
+			if d < tt.base*8/10 || d > tt.base*12/10 {
+				t.Fatalf("backoff(%d) = %v, want %v ±20%%", tt.attempt, d, tt.base)
+			}
+		}
+	}
+}
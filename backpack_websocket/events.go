@@ -0,0 +1,238 @@
+package backpack_websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"backpack_api/backpack_interface"
+)
+
+// DepthEvent 为 depth.<symbol> 流推送的订单簿增量，
+// 数量为 0 的档位表示删除该价格
+type DepthEvent struct {
+	Event         string                          `json:"e"` // Event type
+	EventTime     int64                           `json:"E"` // Event time in microseconds
+	Symbol        string                          `json:"s"` // Symbol
+	Asks          []backpack_interface.PriceLevel `json:"a"` // Asks
+	Bids          []backpack_interface.PriceLevel `json:"b"` // Bids
+	FirstUpdateID int64                           `json:"U"` // First update ID in event
+	LastUpdateID  int64                           `json:"u"` // Last update ID in event
+	EngineTime    int64                           `json:"T"` // Engine timestamp in microseconds
+}
+
+// TradeEvent 为 trade.<symbol> 流推送的公开成交
+type TradeEvent struct {
+	Event         string                     `json:"e"` // Event type
+	EventTime     int64                      `json:"E"` // Event time in microseconds
+	Symbol        string                     `json:"s"` // Symbol
+	Price         backpack_interface.Decimal `json:"p"` // Price
+	Quantity      backpack_interface.Decimal `json:"q"` // Quantity
+	BuyerOrderID  string                     `json:"b"` // Buyer order ID
+	SellerOrderID string                     `json:"a"` // Seller order ID
+	TradeID       int64                      `json:"t"` // Trade ID
+	EngineTime    int64                      `json:"T"` // Engine timestamp in microseconds
+	IsBuyerMaker  bool                       `json:"m"` // Is the buyer the maker?
+}
+
+// TickerEvent 为 ticker.<symbol> 流推送的 24 小时统计
+type TickerEvent struct {
+	Event       string                     `json:"e"` // Event type
+	EventTime   int64                      `json:"E"` // Event time in microseconds
+	Symbol      string                     `json:"s"` // Symbol
+	Open        backpack_interface.Decimal `json:"o"` // First price
+	Close       backpack_interface.Decimal `json:"c"` // Last price
+	High        backpack_interface.Decimal `json:"h"` // High price
+	Low         backpack_interface.Decimal `json:"l"` // Low price
+	Volume      backpack_interface.Decimal `json:"v"` // Base asset volume
+	QuoteVolume backpack_interface.Decimal `json:"V"` // Quote asset volume
+	Trades      int64                      `json:"n"` // Number of trades
+}
+
+// BookTickerEvent 为 bookTicker.<symbol> 流推送的最优买卖价
+type BookTickerEvent struct {
+	Event       string                     `json:"e"` // Event type
+	EventTime   int64                      `json:"E"` // Event time in microseconds
+	Symbol      string                     `json:"s"` // Symbol
+	AskPrice    backpack_interface.Decimal `json:"a"` // Inside ask price
+	AskQuantity backpack_interface.Decimal `json:"A"` // Inside ask quantity
+	BidPrice    backpack_interface.Decimal `json:"b"` // Inside bid price
+	BidQuantity backpack_interface.Decimal `json:"B"` // Inside bid quantity
+	UpdateID    string                     `json:"u"` // Update ID of event
+	EngineTime  int64                      `json:"T"` // Engine timestamp in microseconds
+}
+
+// KlineEvent 为 kline.<interval>.<symbol> 流推送的 K 线
+type KlineEvent struct {
+	Event     string                     `json:"e"` // Event type
+	EventTime int64                      `json:"E"` // Event time in microseconds
+	Symbol    string                     `json:"s"` // Symbol
+	Start     string                     `json:"t"` // K-Line start time
+	End       string                     `json:"T"` // K-Line close time
+	Open      backpack_interface.Decimal `json:"o"` // Open price
+	Close     backpack_interface.Decimal `json:"c"` // Close price
+	High      backpack_interface.Decimal `json:"h"` // High price
+	Low       backpack_interface.Decimal `json:"l"` // Low price
+	Volume    backpack_interface.Decimal `json:"v"` // Base asset volume
+	Trades    int64                      `json:"n"` // Number of trades
+	Closed    bool                       `json:"X"` // Is this k-line closed?
+}
+
+// Dispatcher 拆开 {stream, data} 外层结构，按流名称把事件解码后分发给注册的处理函数。
+// 注册时 symbol 为空表示匹配所有交易对。处理函数在调用 Dispatch 的协程中同步执行。
+type Dispatcher struct {
+	mu          sync.RWMutex
+	depth       map[string][]func(DepthEvent)
+	trade       map[string][]func(TradeEvent)
+	ticker      map[string][]func(TickerEvent)
+	bookTicker  map[string][]func(BookTickerEvent)
+	kline       map[string][]func(KlineEvent)
+	orderUpdate []func(OrderUpdate)
+}
+
+// NewDispatcher 创建空的 Dispatcher
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		depth:      make(map[string][]func(DepthEvent)),
+		trade:      make(map[string][]func(TradeEvent)),
+		ticker:     make(map[string][]func(TickerEvent)),
+		bookTicker: make(map[string][]func(BookTickerEvent)),
+		kline:      make(map[string][]func(KlineEvent)),
+	}
+}
+
+// OnDepth 注册 depth.<symbol> 的处理函数
+func (d *Dispatcher) OnDepth(symbol string, fn func(DepthEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.depth[symbol] = append(d.depth[symbol], fn)
+}
+
+// OnTrade 注册 trade.<symbol> 的处理函数
+func (d *Dispatcher) OnTrade(symbol string, fn func(TradeEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.trade[symbol] = append(d.trade[symbol], fn)
+}
+
+// OnTicker 注册 ticker.<symbol> 的处理函数
+func (d *Dispatcher) OnTicker(symbol string, fn func(TickerEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ticker[symbol] = append(d.ticker[symbol], fn)
+}
+
+// OnBookTicker 注册 bookTicker.<symbol> 的处理函数
+func (d *Dispatcher) OnBookTicker(symbol string, fn func(BookTickerEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.bookTicker[symbol] = append(d.bookTicker[symbol], fn)
+}
+
+// OnKline 注册 kline.<interval>.<symbol> 的处理函数
+func (d *Dispatcher) OnKline(symbol string, interval backpack_interface.KlineInterval, fn func(KlineEvent)) {
+	key := klineKey(string(interval), symbol)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.kline[key] = append(d.kline[key], fn)
+}
+
+// OnOrderUpdate 注册 account.orderUpdate 的处理函数
+func (d *Dispatcher) OnOrderUpdate(fn func(OrderUpdate)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.orderUpdate = append(d.orderUpdate, fn)
+}
+
+func klineKey(interval, symbol string) string {
+	return interval + "." + symbol
+}
+
+// envelope 为推送消息的外层结构
+type envelope struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Dispatch 解码一条原始消息并分发，没有外层结构的消息（例如订阅确认）和未注册的流会被忽略
+func (d *Dispatcher) Dispatch(message []byte) error {
+	env, err := decodeEnvelope(message)
+	if err != nil {
+		return err
+	}
+	return d.dispatch(env)
+}
+
+func decodeEnvelope(message []byte) (envelope, error) {
+	var env envelope
+	if err := json.Unmarshal(message, &env); err != nil {
+		return env, fmt.Errorf("decode message: %w", err)
+	}
+	return env, nil
+}
+
+// dispatch 分发已经拆开外层结构的消息
+func (d *Dispatcher) dispatch(env envelope) error {
+	if env.Stream == "" {
+		return nil
+	}
+
+	// 流名称形如 depth.SOL_USDC、depth.200ms.SOL_USDC、kline.1m.SOL_USDC、account.orderUpdate
+	parts := strings.Split(env.Stream, ".")
+	symbol := parts[len(parts)-1]
+
+	// 在读锁内取出处理函数，在锁外执行，处理函数可以继续注册
+	var run func() error
+	d.mu.RLock()
+	switch parts[0] {
+	case "depth":
+		run = handle(env, collect(d.depth, symbol, ""))
+	case "trade":
+		run = handle(env, collect(d.trade, symbol, ""))
+	case "ticker":
+		run = handle(env, collect(d.ticker, symbol, ""))
+	case "bookTicker":
+		run = handle(env, collect(d.bookTicker, symbol, ""))
+	case "kline":
+		if len(parts) == 3 {
+			run = handle(env, collect(d.kline, klineKey(parts[1], symbol), klineKey(parts[1], "")))
+		}
+	case "account":
+		if len(parts) >= 2 && parts[1] == "orderUpdate" {
+			run = handle(env, append([]func(OrderUpdate){}, d.orderUpdate...))
+		}
+	}
+	d.mu.RUnlock()
+
+	if run == nil {
+		return nil
+	}
+	return run()
+}
+
+// collect 复制 keys 对应的处理函数
+func collect[T any](handlers map[string][]func(T), keys ...string) []func(T) {
+	var fns []func(T)
+	for _, key := range keys {
+		fns = append(fns, handlers[key]...)
+	}
+	return fns
+}
+
+// handle 返回把 env.Data 解码为 T 并调用 fns 的函数，fns 为空时返回 nil 以跳过解码
+func handle[T any](env envelope, fns []func(T)) func() error {
+	if len(fns) == 0 {
+		return nil
+	}
+	return func() error {
+		var ev T
+		if err := json.Unmarshal(env.Data, &ev); err != nil {
+			return fmt.Errorf("decode %s: %w", env.Stream, err)
+		}
+		for _, fn := range fns {
+			fn(ev)
+		}
+		return nil
+	}
+}
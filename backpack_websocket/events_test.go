@@ -0,0 +1,87 @@
+package backpack_websocket
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDispatcherRouting(t *testing.T) {
+	var got []string
+	record := func(s string) { got = append(got, s) }
+
+	d := NewDispatcher()
+	d.OnDepth("SOL_USDC", func(ev DepthEvent) { record("depth SOL_USDC " + ev.Symbol) })
+	d.OnDepth("", func(ev DepthEvent) { record("depth * " + ev.Symbol) })
+	d.OnTrade("SOL_USDC", func(ev TradeEvent) { record("trade SOL_USDC " + ev.Price.String()) })
+	d.OnTicker("BTC_USDC", func(ev TickerEvent) { record("ticker BTC_USDC " + ev.Symbol) })
+	d.OnBookTicker("", func(ev BookTickerEvent) { record("bookTicker * " + ev.Symbol) })
+	d.OnKline("SOL_USDC", "1m", func(ev KlineEvent) { record("kline 1m SOL_USDC " + ev.Start) })
+	d.OnKline("", "1m", func(ev KlineEvent) { record("kline 1m * " + ev.Symbol) })
+	d.OnKline("SOL_USDC", "5m", func(ev KlineEvent) { record("kline 5m SOL_USDC " + ev.Symbol) })
+	d.OnOrderUpdate(func(ev OrderUpdate) { record("orderUpdate " + ev.OrderID) })
+
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"depth", `{"stream":"depth.SOL_USDC","data":{"s":"SOL_USDC"}}`,
+			[]string{"depth SOL_USDC SOL_USDC", "depth * SOL_USDC"}},
+		{"depth with interval", `{"stream":"depth.200ms.SOL_USDC","data":{"s":"SOL_USDC"}}`,
+			[]string{"depth SOL_USDC SOL_USDC", "depth * SOL_USDC"}},
+		{"depth wildcard only", `{"stream":"depth.BTC_USDC","data":{"s":"BTC_USDC"}}`,
+			[]string{"depth * BTC_USDC"}},
+		{"trade", `{"stream":"trade.SOL_USDC","data":{"p":"20.5"}}`, []string{"trade SOL_USDC 20.5"}},
+		{"trade other symbol", `{"stream":"trade.BTC_USDC","data":{"p":"1"}}`, nil},
+		{"ticker", `{"stream":"ticker.BTC_USDC","data":{"s":"BTC_USDC"}}`, []string{"ticker BTC_USDC BTC_USDC"}},
+		{"bookTicker", `{"stream":"bookTicker.ETH_USDC","data":{"s":"ETH_USDC"}}`, []string{"bookTicker * ETH_USDC"}},
+		{"kline", `{"stream":"kline.1m.SOL_USDC","data":{"s":"SOL_USDC","t":"2024-01-01T00:00:00"}}`,
+			[]string{"kline 1m SOL_USDC 2024-01-01T00:00:00", "kline 1m * SOL_USDC"}},
+		{"kline wildcard", `{"stream":"kline.1m.BTC_USDC","data":{"s":"BTC_USDC"}}`, []string{"kline 1m * BTC_USDC"}},
+		{"kline other interval", `{"stream":"kline.5m.SOL_USDC","data":{"s":"SOL_USDC"}}`, []string{"kline 5m SOL_USDC SOL_USDC"}},
+		{"kline unregistered interval", `{"stream":"kline.1h.SOL_USDC","data":{"s":"SOL_USDC"}}`, nil},
+		{"kline without interval", `{"stream":"kline.SOL_USDC","data":{}}`, nil},
+		{"order update", `{"stream":"account.orderUpdate","data":{"i":"111"}}`, []string{"orderUpdate 111"}},
+		{"order update by symbol", `{"stream":"account.orderUpdate.SOL_USDC","data":{"i":"112"}}`, []string{"orderUpdate 112"}},
+		{"subscription ack", `{"result":null,"id":1}`, nil},
+		{"unknown stream", `{"stream":"foo.SOL_USDC","data":{}}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			if err := d.Dispatch([]byte(tt.message)); err != nil {
+				t.Fatalf("Dispatch: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("handlers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherErrors(t *testing.T) {
+	d := NewDispatcher()
+	d.OnTrade("", func(TradeEvent) { t.Error("handler called for bad data") })
+	tests := []struct {
+		message string
+		want    string // 错误前缀，为空表示不报错
+	}{
+		{`not json`, "decode message"},
+		{`{"stream":"trade.SOL_USDC","data":{"p":"abc"}}`, "decode trade.SOL_USDC"},
+		// 没有注册处理函数的流不解码
+		{`{"stream":"depth.SOL_USDC","data":{"a":"abc"}}`, ""},
+	}
+	for _, tt := range tests {
+		err := d.Dispatch([]byte(tt.message))
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("Dispatch(%s) = %v, want nil", tt.message, err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("Dispatch(%s) = %v, want %s error", tt.message, err, tt.want)
+		}
+	}
+}
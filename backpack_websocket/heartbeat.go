@@ -0,0 +1,153 @@
+package backpack_websocket
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"time"
+	"websocket-main"
+)
+
+// 默认的心跳参数
+const (
+	DefaultPingInterval = 20 * time.Second
+	DefaultReadTimeout  = 60 * time.Second
+)
+
+// ErrStale 表示连接在读超时时间内没有收到任何数据（包括 pong），客户端会重连
+var ErrStale = errors.New("backpack websocket: connection stale")
+
+// WithPingInterval 设置发送 ping 的间隔，为 0 时不主动发送 ping
+func WithPingInterval(d time.Duration) Option {
+	return func(c *WebSocketClient) { c.pingInterval = d }
+}
+
+// WithReadTimeout 设置读空闲超时，超过该时间没有收到消息、ping 或 pong 即视为连接失效并重连。
+// 为 0 时不设置读超时。
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *WebSocketClient) { c.readTimeout = d }
+}
+
+// WithStaleStreamHandler 在每次心跳时检查订阅的流，超过 threshold 没有消息的流交给 fn。
+// 需要 ping 间隔大于 0；fn 在心跳协程中执行，不应阻塞。
+func WithStaleStreamHandler(threshold time.Duration, fn func(stream string, age time.Duration)) Option {
+	return func(c *WebSocketClient) {
+		c.staleThreshold = threshold
+		c.onStaleStream = fn
+	}
+}
+
+// ClientStats 为 WebSocketClient 的运行指标
+type ClientStats struct {
+	Messages        uint64    // 收到的消息数
+	Reconnects      uint64    // 断线重连的次数
+	StaleReconnects uint64    // 因读超时而重连的次数
+	LastMessage     time.Time // 最近一次收到消息的时间
+}
+
+// Stats 返回运行指标的快照
+func (client *WebSocketClient) Stats() ClientStats {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.stats
+}
+
+// StreamAges 返回每个订阅的流距离最近一条消息的时间，还没有收到消息的流从订阅时开始计算
+func (client *WebSocketClient) StreamAges() map[string]time.Duration {
+	now := time.Now()
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	ages := make(map[string]time.Duration, len(client.subs))
+	for _, stream := range client.subs {
+		ages[stream] = now.Sub(client.lastSeen[stream])
+	}
+	return ages
+}
+
+// StaleStreams 返回超过 threshold 没有消息的订阅流，按名称排序
+func (client *WebSocketClient) StaleStreams(threshold time.Duration) []string {
+	var stale []string
+	for stream, age := range client.StreamAges() {
+		if age > threshold {
+			stale = append(stale, stream)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// touch 记录流收到消息的时间
+func (client *WebSocketClient) touch(stream string, now time.Time) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.stats.Messages++
+	client.stats.LastMessage = now
+	if stream != "" && contains(client.subs, stream) {
+		client.lastSeen[stream] = now
+	}
+}
+
+// setupHeartbeat 设置读超时，并在收到 ping 或 pong 时延长
+func (client *WebSocketClient) setupHeartbeat(conn *websocket.Conn) {
+	client.extendReadDeadline(conn)
+	conn.SetPongHandler(func(string) error {
+		client.extendReadDeadline(conn)
+		return nil
+	})
+	conn.SetPingHandler(func(data string) error {
+		client.extendReadDeadline(conn)
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+		if err == websocket.ErrCloseSent || isTimeout(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (client *WebSocketClient) extendReadDeadline(conn *websocket.Conn) {
+	if client.readTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(client.readTimeout))
+	}
+}
+
+// heartbeat 按间隔发送 ping 并检查安静的流，直到 stop 关闭
+func (client *WebSocketClient) heartbeat(conn *websocket.Conn, stop <-chan struct{}) {
+	if client.pingInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(client.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		// ping 失败时由读循环发现连接断开
+		conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+
+		if client.onStaleStream != nil {
+			for stream, age := range client.StreamAges() {
+				if age > client.staleThreshold {
+					client.onStaleStream(stream, age)
+				}
+			}
+		}
+	}
+}
+
+// readError 把读超时转换为 ErrStale 并计数
+func (client *WebSocketClient) readError(err error) error {
+	if !isTimeout(err) {
+		return err
+	}
+	client.mu.Lock()
+	client.stats.StaleReconnects++
+	client.mu.Unlock()
+	return errors.Join(ErrStale, err)
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,106 @@
+package backpack_websocket
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadTimeoutReconnects(t *testing.T) {
+	url, conns := newTestServer(t)
+	client := NewWebSocketClient(WithURL(url), WithPingInterval(0), WithReadTimeout(100*time.Millisecond),
+		WithReconnectBackoff(10*time.Millisecond, 50*time.Millisecond))
+	serveClient(t, client)
+
+	// 服务器不发送任何数据，读超时后以 ErrStale 重连
+	accept(t, conns)
+	waitState(t, client, StateConnected)
+	ev := waitState(t, client, StateReconnecting)
+	if ev.Attempt != 1 || !errors.Is(ev.Err, ErrStale) {
+		t.Fatalf("reconnecting event = %+v, want attempt 1 with ErrStale", ev)
+	}
+	accept(t, conns)
+	waitState(t, client, StateConnected)
+	if st := client.Stats(); st.Reconnects != 1 || st.StaleReconnects != 1 {
+		t.Errorf("Stats = %+v, want 1 stale reconnect", st)
+	}
+}
+
+func TestPingKeepsConnectionAlive(t *testing.T) {
+	url, conns := newTestServer(t)
+	stale := make(chan string, 1)
+	client := NewWebSocketClient(WithURL(url), WithPingInterval(20*time.Millisecond), WithReadTimeout(100*time.Millisecond),
+		WithStaleStreamHandler(50*time.Millisecond, func(stream string, age time.Duration) {
+			select {
+			case stale <- stream:
+			default:
+			}
+		}))
+	if err := client.Subscribe(context.Background(), "trade.SOL_USDC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	serveClient(t, client)
+
+	// 服务器读取消息时自动回复 pong，连接不会超时
+	conn := accept(t, conns)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	waitState(t, client, StateConnected)
+
+	// 流一直没有消息，心跳时报告
+	select {
+	case stream := <-stale:
+		if stream != "trade.SOL_USDC" {
+			t.Errorf("stale stream = %s", stream)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stale stream not reported")
+	}
+	time.Sleep(300 * time.Millisecond)
+	select {
+	case ev := <-client.States():
+		t.Errorf("unexpected state %+v", ev)
+	default:
+	}
+	if st := client.Stats(); st.Reconnects != 0 || st.StaleReconnects != 0 {
+		t.Errorf("Stats = %+v, want no reconnects", st)
+	}
+}
+
+func TestStaleStreams(t *testing.T) {
+	client := NewWebSocketClient()
+	ctx := context.Background()
+	client.Subscribe(ctx, "depth.SOL_USDC")
+	client.Subscribe(ctx, "trade.SOL_USDC")
+	client.Subscribe(ctx, "ticker.SOL_USDC")
+
+	now := time.Now()
+	client.touch("depth.SOL_USDC", now.Add(-time.Minute))
+	client.touch("trade.SOL_USDC", now.Add(-2*time.Minute))
+	client.touch("ticker.SOL_USDC", now)
+	// 未订阅的流只计入消息数
+	client.touch("kline.1m.SOL_USDC", now)
+	client.touch("", now)
+
+	if got := client.StaleStreams(30 * time.Second); !reflect.DeepEqual(got, []string{"depth.SOL_USDC", "trade.SOL_USDC"}) {
+		t.Errorf("StaleStreams = %v", got)
+	}
+	if got := client.StreamAges(); len(got) != 3 || got["trade.SOL_USDC"] < 2*time.Minute {
+		t.Errorf("StreamAges = %v", got)
+	}
+	if st := client.Stats(); st.Messages != 5 || !st.LastMessage.Equal(now) {
+		t.Errorf("Stats = %+v, want 5 messages", st)
+	}
+
+	client.Unsubscribe(ctx, "trade.SOL_USDC")
+	if got := client.StaleStreams(30 * time.Second); !reflect.DeepEqual(got, []string{"depth.SOL_USDC"}) {
+		t.Errorf("StaleStreams after Unsubscribe = %v", got)
+	}
+}
@@ -0,0 +1,304 @@
+package backpack_websocket
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"backpack_api/backpack_interface"
+)
+
+// DefaultCandleHistory 为 KlineAggregator 默认保留的已收盘 K 线数量
+const DefaultCandleHistory = 1000
+
+// seedTradeLimit 为 getRecentTrades 单次最多返回的成交数
+const seedTradeLimit = 1000
+
+// klineTimeLayouts 为 REST K 线 start 字段可能的格式
+var klineTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// Candle 为聚合得到的一根 K 线，时间区间为 [Start, End)
+type Candle struct {
+	Symbol      string
+	Interval    time.Duration
+	Start       time.Time
+	End         time.Time
+	Open        backpack_interface.Decimal
+	High        backpack_interface.Decimal
+	Low         backpack_interface.Decimal
+	Close       backpack_interface.Decimal
+	Volume      backpack_interface.Decimal // 基础资产成交量
+	QuoteVolume backpack_interface.Decimal // 计价资产成交额，从 K 线历史播种的部分不包含
+	Trades      int64
+	Closed      bool // 为 false 时是仍在更新的当前 K 线
+}
+
+// KlineAggregator 用 trade 流的成交生成任意周期的 K 线，包括交易所不提供的周期（例如 7s、90s）。
+// 每笔成交后以未收盘的 Candle 调用回调，进入下一个周期或 Run 到点时以收盘的 Candle 调用回调。
+// 没有成交的周期不会生成 K 线。早于当前 K 线或属于已收盘周期的成交会被忽略。
+type KlineAggregator struct {
+	symbol   string
+	interval time.Duration
+	onCandle func(Candle)
+	history  int
+
+	mu        sync.Mutex
+	cur       *Candle
+	closedEnd time.Time // 最近收盘的 K 线的结束时间，之前的成交都算迟到
+	candles   []Candle  // 已收盘，按时间升序
+	late      uint64
+}
+
+// NewKlineAggregator 创建 symbol 的 K 线聚合器，interval 必须大于 0，fn 可以为 nil
+func NewKlineAggregator(symbol string, interval time.Duration, fn func(Candle)) (*KlineAggregator, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("backpack websocket: kline interval must be positive, got %s", interval)
+	}
+	return &KlineAggregator{
+		symbol:   symbol,
+		interval: interval,
+		onCandle: fn,
+		history:  DefaultCandleHistory,
+	}, nil
+}
+
+// Attach 在 ws 上注册 trade 处理函数并订阅 trade.<symbol>
+func (a *KlineAggregator) Attach(ctx context.Context, ws *WebSocketClient) error {
+	ws.OnTrade(a.symbol, a.AddTrade)
+	return ws.Subscribe(ctx, "trade."+a.symbol)
+}
+
+// Seed 从 REST 历史播种 since 之后的 K 线，不触发回调。
+// 交易所有能整除目标周期的 K 线周期时用 getKLines 合并，否则（例如 7s、90s）
+// 只能重放最近的 1000 笔成交；这些成交覆盖不到 since 时不播种并返回错误。
+// 应在 Attach 之前调用，播种与订阅之间的成交可能缺失。
+func (a *KlineAggregator) Seed(ctx context.Context, rest *backpack_interface.Client, since time.Time) error {
+	intervals := backpack_interface.KlineIntervals
+	for i := len(intervals) - 1; i >= 0; i-- {
+		if d := intervals[i].Duration(); d > 0 && a.interval%d == 0 {
+			return a.seedKlines(ctx, rest, intervals[i], since)
+		}
+	}
+	return a.seedTrades(ctx, rest, since)
+}
+
+func (a *KlineAggregator) seedKlines(
+	ctx context.Context,
+	rest *backpack_interface.Client,
+	interval backpack_interface.KlineInterval,
+	since time.Time,
+) error {
+	klines, err := rest.GetKLines(ctx, backpack_interface.Klines{
+		Symbol:    a.symbol,
+		Interval:  interval,
+		StartTime: int(since.Unix()),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, k := range klines {
+		start, err := parseKlineTime(k.Start)
+		if err != nil {
+			return fmt.Errorf("kline %s %s: %w", a.symbol, interval, err)
+		}
+		trades, _ := strconv.ParseInt(k.Trades, 10, 64)
+		if _, fresh := a.roll(start); fresh {
+			a.cur.Open, a.cur.High, a.cur.Low = k.Open, k.High, k.Low
+		}
+		c := a.cur
+		if k.High.Cmp(c.High) > 0 {
+			c.High = k.High
+		}
+		if k.Low.Cmp(c.Low) < 0 {
+			c.Low = k.Low
+		}
+		c.Close = k.Close
+		c.Volume = c.Volume.Add(k.Volume)
+		c.Trades += trades
+	}
+	if a.cur != nil && !now.Before(a.cur.End) {
+		a.closeCurrent()
+	}
+	return nil
+}
+
+func (a *KlineAggregator) seedTrades(ctx context.Context, rest *backpack_interface.Client, since time.Time) error {
+	trades, err := rest.GetRecentTrades(ctx, a.symbol, seedTradeLimit)
+	if err != nil {
+		return err
+	}
+	sort.Slice(trades, func(i, j int) bool { return trades[i].Timestamp < trades[j].Timestamp })
+	// 返回满额时更早的成交可能被截掉，since 之后的 K 线会不完整
+	if len(trades) >= seedTradeLimit {
+		if oldest := time.UnixMilli(trades[0].Timestamp); oldest.After(since) {
+			return fmt.Errorf("seed %s: recent trades only go back to %s, after %s",
+				a.symbol, oldest.Format(time.RFC3339), since.Format(time.RFC3339))
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, t := range trades {
+		ts := time.UnixMilli(t.Timestamp)
+		if ts.Before(since) {
+			continue
+		}
+		a.add(ts, t.Price, t.Quantity)
+	}
+	return nil
+}
+
+// AddTrade 把一笔 trade 流的成交计入 K 线
+func (a *KlineAggregator) AddTrade(ev TradeEvent) {
+	a.mu.Lock()
+	closed, cur, ok := a.add(time.UnixMicro(ev.EngineTime), ev.Price, ev.Quantity)
+	a.mu.Unlock()
+
+	if a.onCandle == nil || !ok {
+		return
+	}
+	if closed != nil {
+		a.onCandle(*closed)
+	}
+	a.onCandle(cur)
+}
+
+// add 计入一笔成交，返回因此收盘的 K 线和更新后的当前 K 线；成交迟到时 ok 为 false
+func (a *KlineAggregator) add(
+	ts time.Time,
+	price, quantity backpack_interface.Decimal,
+) (closed *Candle, cur Candle, ok bool) {
+	start := ts.Truncate(a.interval)
+	if (a.cur != nil && start.Before(a.cur.Start)) || start.Before(a.closedEnd) {
+		a.late++
+		return nil, cur, false
+	}
+
+	closed, fresh := a.roll(start)
+	c := a.cur
+	if fresh {
+		c.Open, c.High, c.Low = price, price, price
+	}
+	if price.Cmp(c.High) > 0 {
+		c.High = price
+	}
+	if price.Cmp(c.Low) < 0 {
+		c.Low = price
+	}
+	c.Close = price
+	c.Volume = c.Volume.Add(quantity)
+	c.QuoteVolume = c.QuoteVolume.Add(price.Mul(quantity))
+	c.Trades++
+	return closed, *c, true
+}
+
+// roll 使当前 K 线为包含 start 的周期，旧的 K 线先收盘。
+// 返回因此收盘的 K 线，以及当前 K 线是否为新建。
+func (a *KlineAggregator) roll(start time.Time) (closed *Candle, fresh bool) {
+	start = start.Truncate(a.interval)
+	if a.cur != nil && !start.Equal(a.cur.Start) {
+		c := a.closeCurrent()
+		closed = &c
+	}
+	if a.cur != nil {
+		return closed, false
+	}
+	a.cur = &Candle{
+		Symbol:   a.symbol,
+		Interval: a.interval,
+		Start:    start,
+		End:      start.Add(a.interval),
+	}
+	return closed, true
+}
+
+// closeCurrent 收盘当前 K 线并保存到历史
+func (a *KlineAggregator) closeCurrent() Candle {
+	c := *a.cur
+	c.Closed = true
+	a.cur = nil
+	a.closedEnd = c.End
+	a.candles = append(a.candles, c)
+	if len(a.candles) > a.history {
+		a.candles = a.candles[len(a.candles)-a.history:]
+	}
+	return c
+}
+
+// Flush 在 now 已经超过当前 K 线的结束时间时将其收盘并触发回调
+func (a *KlineAggregator) Flush(now time.Time) {
+	a.mu.Lock()
+	if a.cur == nil || now.Before(a.cur.End) {
+		a.mu.Unlock()
+		return
+	}
+	c := a.closeCurrent()
+	a.mu.Unlock()
+
+	if a.onCandle != nil {
+		a.onCandle(c)
+	}
+}
+
+// Run 在每个周期结束时调用 Flush，使没有后续成交的 K 线也能按时收盘，直到 ctx 取消
+func (a *KlineAggregator) Run(ctx context.Context) error {
+	for {
+		now := time.Now()
+		next := now.Truncate(a.interval).Add(a.interval)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case t := <-timer.C:
+			a.Flush(t)
+		}
+	}
+}
+
+// Current 返回未收盘的当前 K 线
+func (a *KlineAggregator) Current() (Candle, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cur == nil {
+		return Candle{}, false
+	}
+	return *a.cur, true
+}
+
+// Candles 返回保留的已收盘 K 线，按时间升序
+func (a *KlineAggregator) Candles() []Candle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Candle(nil), a.candles...)
+}
+
+// Late 返回因迟到而被忽略的成交数
+func (a *KlineAggregator) Late() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.late
+}
+
+func parseKlineTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	for _, layout := range klineTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
@@ -0,0 +1,284 @@
+package backpack_websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backpack_api/backpack_interface"
+)
+
+const testKlineStart = 1700000000 // 秒，是 10s 的整数倍
+
+func tradeAt(sec float64, price, quantity string) TradeEvent {
+	return TradeEvent{
+		Symbol:     "SOL_USDC",
+		Price:      backpack_interface.MustDecimal(price),
+		Quantity:   backpack_interface.MustDecimal(quantity),
+		EngineTime: int64((testKlineStart + sec) * 1e6),
+	}
+}
+
+func newTestAggregator(t *testing.T, fn func(Candle)) *KlineAggregator {
+	t.Helper()
+	a, err := NewKlineAggregator("SOL_USDC", 10*time.Second, fn)
+	if err != nil {
+		t.Fatalf("NewKlineAggregator: %v", err)
+	}
+	return a
+}
+
+func TestKlineAggregatorRejectsInterval(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		if _, err := NewKlineAggregator("SOL_USDC", d, nil); err == nil {
+			t.Errorf("NewKlineAggregator(%s): want error", d)
+		}
+	}
+}
+
+func TestKlineAggregatorCandles(t *testing.T) {
+	var closed []Candle
+	a := newTestAggregator(t, func(c Candle) {
+		if c.Closed {
+			closed = append(closed, c)
+		}
+	})
+	a.AddTrade(tradeAt(1, "10", "1"))
+	a.AddTrade(tradeAt(2, "12", "2"))
+	a.AddTrade(tradeAt(3, "9", "1"))
+	a.AddTrade(tradeAt(12, "11", "1")) // 进入下一个周期
+
+	if len(closed) != 1 {
+		t.Fatalf("closed %d candles, want 1", len(closed))
+	}
+	c := closed[0]
+	d := backpack_interface.MustDecimal
+	if !c.Start.Equal(time.Unix(testKlineStart, 0)) || !c.End.Equal(time.Unix(testKlineStart+10, 0)) {
+		t.Errorf("candle [%s, %s), want start %d", c.Start, c.End, testKlineStart)
+	}
+	if !c.Open.Equal(d("10")) || !c.High.Equal(d("12")) || !c.Low.Equal(d("9")) || !c.Close.Equal(d("9")) {
+		t.Errorf("OHLC = %s %s %s %s, want 10 12 9 9", c.Open, c.High, c.Low, c.Close)
+	}
+	if !c.Volume.Equal(d("4")) || !c.QuoteVolume.Equal(d("43")) || c.Trades != 3 {
+		t.Errorf("Volume = %s QuoteVolume = %s Trades = %d, want 4 43 3", c.Volume, c.QuoteVolume, c.Trades)
+	}
+	if cur, ok := a.Current(); !ok || !cur.Open.Equal(d("11")) {
+		t.Errorf("Current = %+v %v, want open 11", cur, ok)
+	}
+}
+
+func TestKlineAggregatorLateTradeAfterFlush(t *testing.T) {
+	var closed int
+	a := newTestAggregator(t, func(c Candle) {
+		if c.Closed {
+			closed++
+		}
+	})
+	a.AddTrade(tradeAt(1, "10", "1"))
+	a.Flush(time.Unix(testKlineStart+10, 0))
+
+	// 计时器触发后才到达的上一个周期的成交
+	a.AddTrade(tradeAt(9.9, "11", "1"))
+	a.Flush(time.Unix(testKlineStart+20, 0))
+
+	if closed != 1 || len(a.Candles()) != 1 {
+		t.Fatalf("closed %d candles, history %d, want 1", closed, len(a.Candles()))
+	}
+	if a.Late() != 1 {
+		t.Fatalf("Late = %d, want 1", a.Late())
+	}
+	if _, ok := a.Current(); ok {
+		t.Fatal("late trade reopened a candle")
+	}
+
+	// 下一个周期的成交不受影响
+	a.AddTrade(tradeAt(10, "12", "1"))
+	if cur, ok := a.Current(); !ok || !cur.Start.Equal(time.Unix(testKlineStart+10, 0)) {
+		t.Fatalf("Current = %+v %v, want candle at %d", cur, ok, testKlineStart+10)
+	}
+}
+
+func TestKlineAggregatorSeedKlines(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		interval time.Duration
+		fetch    string // 向交易所请求的周期
+		klines   string
+		want     []string // 每根 K 线的 start OHLC volume trades
+	}{
+		{
+			name:     "1m into 2m",
+			interval: 2 * time.Minute,
+			fetch:    "1m",
+			klines: `[
+				{"start":"2024-01-01T00:00:00","open":"10","high":"12","low":"9","close":"11","volume":"1","trades":"2"},
+				{"start":"2024-01-01T00:01:00","open":"11","high":"13","low":"10","close":"12","volume":"2","trades":"3"},
+				{"start":"2024-01-01T00:02:00","open":"12","high":"12","low":"8","close":"8","volume":"4","trades":"1"},
+				{"start":"2024-01-01T00:03:00","open":"8","high":"9","low":"8","close":"9","volume":"1","trades":"1"}
+			]`,
+			want: []string{
+				"00:00 10 13 9 12 3 5",
+				"00:02 12 12 8 9 5 2",
+			},
+		},
+		{
+			name:     "1m into 7m",
+			interval: 7 * time.Minute,
+			fetch:    "1m",
+			klines: `[
+				{"start":"2024-01-01 00:06:00","open":"10","high":"10","low":"10","close":"10","volume":"1","trades":"1"},
+				{"start":"2024-01-01 00:07:00","open":"11","high":"11","low":"11","close":"11","volume":"1","trades":"1"}
+			]`,
+			want: []string{
+				"00:00 10 10 10 10 1 1",
+				"00:07 11 11 11 11 1 1",
+			},
+		},
+		{
+			name:     "5m into 10m",
+			interval: 10 * time.Minute,
+			fetch:    "5m",
+			klines: `[
+				{"start":"1704067200","open":"10","high":"11","low":"10","close":"11","volume":"1","trades":"1"},
+				{"start":"1704067500","open":"11","high":"11","low":"7","close":"7","volume":"1","trades":"1"}
+			]`,
+			want: []string{"00:00 10 11 7 7 2 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if r.URL.Path != "/api/v1/klines" || q.Get("interval") != tt.fetch ||
+					q.Get("symbol") != "SOL_USDC" || q.Get("startTime") != fmt.Sprint(since.Unix()) {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.Write([]byte(tt.klines))
+			})
+			a, err := NewKlineAggregator("SOL_USDC", tt.interval, func(Candle) { t.Error("Seed triggered callback") })
+			if err != nil {
+				t.Fatalf("NewKlineAggregator: %v", err)
+			}
+			if err := a.Seed(context.Background(), rest, since); err != nil {
+				t.Fatalf("Seed: %v", err)
+			}
+			var got []string
+			for _, c := range a.Candles() {
+				if !c.Closed || !c.End.Equal(c.Start.Add(tt.interval)) || !c.QuoteVolume.IsZero() {
+					t.Errorf("candle %+v", c)
+				}
+				got = append(got, fmt.Sprintf("%s %s %s %s %s %s %d", c.Start.Format("15:04"),
+					c.Open, c.High, c.Low, c.Close, c.Volume, c.Trades))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("candles:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if _, ok := a.Current(); ok {
+				t.Error("past candle left open")
+			}
+		})
+	}
+}
+
+// recentTrades 生成 n 笔成交，从 start 开始每秒一笔，价格依次为 1..n，按时间倒序
+func recentTrades(start time.Time, n int) string {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := n - 1; i >= 0; i-- {
+		if i < n-1 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":%d,"price":"%d","quantity":"1","timestamp":%d}`,
+			i, i+1, start.Add(time.Duration(i)*time.Second).UnixMilli())
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+func TestKlineAggregatorSeedTrades(t *testing.T) {
+	start := time.Unix(testKlineStart, 0).Truncate(7 * time.Second)
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/trades" || r.URL.Query().Get("limit") != "1000" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(recentTrades(start, 20)))
+	})
+	// 7s 不是交易所的 K 线周期，用最近的成交重放
+	a, err := NewKlineAggregator("SOL_USDC", 7*time.Second, nil)
+	if err != nil {
+		t.Fatalf("NewKlineAggregator: %v", err)
+	}
+	if err := a.Seed(context.Background(), rest, start.Add(5*time.Second)); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+
+	// since 之前的 5 笔成交被跳过
+	candles := a.Candles()
+	if len(candles) != 2 {
+		t.Fatalf("got %d closed candles, want 2", len(candles))
+	}
+	d := backpack_interface.MustDecimal
+	if c := candles[0]; !c.Start.Equal(start) || !c.Open.Equal(d("6")) || !c.Close.Equal(d("7")) || c.Trades != 2 {
+		t.Errorf("candles[0] = %+v, want trades 6..7", c)
+	}
+	if c := candles[1]; !c.Open.Equal(d("8")) || !c.Close.Equal(d("14")) || c.Trades != 7 || !c.QuoteVolume.Equal(d("77")) {
+		t.Errorf("candles[1] = %+v, want trades 8..14", c)
+	}
+	if cur, ok := a.Current(); !ok || !cur.Open.Equal(d("15")) || cur.Trades != 6 {
+		t.Errorf("Current = %+v %v, want trades 15..20", cur, ok)
+	}
+}
+
+func TestKlineAggregatorSeedTradesIncomplete(t *testing.T) {
+	start := time.Unix(testKlineStart, 0)
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(recentTrades(start, 1000)))
+	})
+	a, err := NewKlineAggregator("SOL_USDC", 90*time.Second, nil)
+	if err != nil {
+		t.Fatalf("NewKlineAggregator: %v", err)
+	}
+	// 返回满额且最早的成交晚于 since，更早的成交可能缺失
+	if err := a.Seed(context.Background(), rest, start.Add(-time.Second)); err == nil {
+		t.Fatal("Seed: want error when trades do not reach since")
+	}
+	if _, ok := a.Current(); ok || len(a.Candles()) != 0 {
+		t.Error("incomplete seed left candles")
+	}
+	if err := a.Seed(context.Background(), rest, start); err != nil {
+		t.Fatalf("Seed covering since: %v", err)
+	}
+}
+
+func TestParseKlineTime(t *testing.T) {
+	want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"2024-01-01T00:00:00", false},
+		{"2024-01-01 00:00:00", false},
+		{"2024-01-01T08:00:00+08:00", false},
+		{"2024-01-01T00:00:00Z", false},
+		{"1704067200", false},
+		{"", true},
+		{"2024-01-01", true},
+		{"yesterday", true},
+	}
+	for _, tt := range tests {
+		got, err := parseKlineTime(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseKlineTime(%q) = %s, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseKlineTime(%q) = %s, %v, want %s", tt.in, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,596 @@
+package backpack_websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"backpack_api/backpack_interface"
+)
+
+// 本地订单状态，其余取值与交易所的订单状态相同
+const (
+	OrderPending  backpack_interface.OrderStatus = "Pending"  // 已提交，尚未收到响应
+	OrderRejected backpack_interface.OrderStatus = "Rejected" // 下单请求被拒绝
+	// 下单请求超时或服务端出错，交易所可能已经接受订单，等待推送或对账确定状态
+	OrderUnknown backpack_interface.OrderStatus = "Unknown"
+	// Unknown 订单超过 unknownTimeout 仍未在推送、挂单和订单历史中找到
+	OrderLost backpack_interface.OrderStatus = "Lost"
+)
+
+const (
+	// reconcileGrace 为对账时的宽限期，更新时间在此之内的订单不因不在挂单列表中而查询历史
+	reconcileGrace = 5 * time.Second
+	// unknownTimeout 为 Unknown 订单等待确定状态的时间，之后记为 Lost
+	unknownTimeout = time.Minute
+	// unknownHistoryLimit 为查找 Unknown 订单时读取的订单历史条数
+	unknownHistoryLimit = 100
+	// defaultOrderRetention 为结束的订单默认保留的时间
+	defaultOrderRetention = 10 * time.Minute
+)
+
+// TrackedOrder 为 OrderManager 合并后的订单状态
+type TrackedOrder struct {
+	OrderID               string
+	ClientID              string
+	Symbol                string
+	Side                  backpack_interface.Side
+	OrderType             backpack_interface.OrderType
+	Price                 backpack_interface.Decimal
+	TriggerPrice          backpack_interface.Decimal
+	Quantity              backpack_interface.Decimal
+	ExecutedQuantity      backpack_interface.Decimal
+	ExecutedQuoteQuantity backpack_interface.Decimal
+	Status                backpack_interface.OrderStatus
+	Fills                 int       // 收到的成交推送数
+	EngineTime            int64     // 最近一次应用的推送的引擎时间（微秒）
+	SubmittedAt           time.Time // 本地发送下单请求的时间，推送或对账发现的订单为零值
+	UpdatedAt             time.Time // 本地最近一次更新的时间
+	Err                   error     // 下单请求的错误，Status 为 Rejected、Unknown 或 Lost 时有值
+}
+
+// Terminal 判断订单是否已经结束
+func (o TrackedOrder) Terminal() bool {
+	return statusRank(o.Status) >= 3
+}
+
+// statusRank 为状态的先后顺序，乱序到达的推送不能让订单回到更早的状态
+func statusRank(status backpack_interface.OrderStatus) int {
+	switch status {
+	case OrderPending, OrderUnknown:
+		return 0
+	case backpack_interface.OrderStatusNew, backpack_interface.OrderStatusTriggerPending:
+		return 1
+	case backpack_interface.OrderStatusPartiallyFilled:
+		return 2
+	case backpack_interface.OrderStatusFilled, backpack_interface.OrderStatusCancelled,
+		backpack_interface.OrderStatusExpired, backpack_interface.OrderStatusTriggerFailed, OrderRejected, OrderLost:
+		return 3
+	}
+	return 1
+}
+
+// OrderManager 从下单开始按 orderId 和 clientId 记录订单，
+// 合并下单响应、订单更新推送和定期对账得到的状态。
+// 推送按引擎时间排序应用，较早的推送只能推进成交数量和更靠后的状态。
+// clientId 可以复用，结束的订单在新订单使用同一 clientId 后只能按 orderId 查询。
+type OrderManager struct {
+	rest *backpack_interface.Client
+
+	// Retention 为结束的订单在最后一次更新后保留的时间，对账时删除过期的订单，为 0 时不删除
+	Retention time.Duration
+
+	mu       sync.RWMutex
+	orders   []*TrackedOrder
+	byID     map[string]*TrackedOrder
+	byClient map[string]*TrackedOrder
+	trades   map[string]map[string]bool // 已计入的成交，按 orderId 和 tradeId 去重
+	watchers map[*TrackedOrder][]chan TrackedOrder
+	onChange []func(TrackedOrder)
+	now      func() time.Time
+}
+
+// NewOrderManager 创建订单管理器
+func NewOrderManager(rest *backpack_interface.Client) *OrderManager {
+	return &OrderManager{
+		rest:      rest,
+		Retention: defaultOrderRetention,
+		byID:      make(map[string]*TrackedOrder),
+		byClient:  make(map[string]*TrackedOrder),
+		trades:    make(map[string]map[string]bool),
+		watchers:  make(map[*TrackedOrder][]chan TrackedOrder),
+		now:       time.Now,
+	}
+}
+
+// Attach 在 ws 上注册订单更新处理函数并订阅 account.orderUpdate，ws 需要配置签名器
+func (m *OrderManager) Attach(ctx context.Context, ws *WebSocketClient) error {
+	ws.OnOrderUpdate(m.Apply)
+	return ws.SubscribePrivate(ctx, "account.orderUpdate")
+}
+
+// OnChange 注册订单变化的处理函数，在持有锁之外同步调用
+func (m *OrderManager) OnChange(fn func(TrackedOrder)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, fn)
+}
+
+// Watch 返回 id（orderId 或 clientId）对应订单的变化通道，调用 cancel 后停止通知。
+// 订单还不存在时返回 nil。通道满时丢弃最旧的通知，最后一条总是最新状态。
+func (m *OrderManager) Watch(id string) (<-chan TrackedOrder, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o := m.find(id)
+	if o == nil {
+		return nil, func() {}
+	}
+	ch := make(chan TrackedOrder, 16)
+	ch <- *o
+	m.watchers[o] = append(m.watchers[o], ch)
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		list := m.watchers[o]
+		for i, c := range list {
+			if c == ch {
+				m.watchers[o] = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Get 按 orderId 或 clientId 查询订单
+func (m *OrderManager) Get(id string) (TrackedOrder, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if o := m.find(id); o != nil {
+		return *o, true
+	}
+	return TrackedOrder{}, false
+}
+
+// Snapshot 返回所有订单的一致快照，按记录顺序排列
+func (m *OrderManager) Snapshot() []TrackedOrder {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rst := make([]TrackedOrder, len(m.orders))
+	for i, o := range m.orders {
+		rst[i] = *o
+	}
+	return rst
+}
+
+// Open 返回尚未结束的订单
+func (m *OrderManager) Open() []TrackedOrder {
+	var rst []TrackedOrder
+	for _, o := range m.Snapshot() {
+		if !o.Terminal() {
+			rst = append(rst, o)
+		}
+	}
+	return rst
+}
+
+// Submit 记录并发送订单。带 clientId 的订单在发送前就可以用 clientId 查询。
+// 只有本地校验失败或交易所返回 4xx 时订单记为 Rejected；超时、网络错误和 5xx
+// 记为 Unknown，之后由推送或对账确定状态。没有 clientId 的 Unknown 订单按交易对、
+// 方向、价格和数量匹配，超过 unknownTimeout 仍未找到时记为 Lost。
+func (m *OrderManager) Submit(ctx context.Context, co backpack_interface.CreateOrder) (TrackedOrder, error) {
+	now := m.now()
+	pending := &TrackedOrder{
+		ClientID:    clientIDString(co.ClientId),
+		Symbol:      co.Symbol,
+		Side:        co.Side,
+		OrderType:   co.OrderType,
+		Price:       co.Price,
+		Quantity:    co.Quantity,
+		Status:      OrderPending,
+		SubmittedAt: now,
+		UpdatedAt:   now,
+	}
+	m.mu.Lock()
+	o := m.track(pending)
+	m.mu.Unlock()
+
+	order, err := m.rest.CreateOrder(ctx, co)
+
+	m.mu.Lock()
+	if err != nil {
+		if o.Status == OrderPending {
+			o.Status = OrderUnknown
+			if rejected(err) {
+				o.Status = OrderRejected
+			}
+			o.Err = err
+			o.UpdatedAt = m.now()
+		}
+	} else {
+		// 没有 clientId 时推送可能先于响应到达并已单独记录
+		if existing := m.byID[order.ID]; existing != nil && existing != o {
+			m.remove(o)
+			o = existing
+		}
+		m.mergeOrder(o, *order)
+	}
+	snapshot := *o
+	notify := m.changed(o)
+	m.mu.Unlock()
+
+	notify()
+	return snapshot, err
+}
+
+// Apply 应用一条订单更新推送
+func (m *OrderManager) Apply(ev OrderUpdate) {
+	m.mu.Lock()
+	o := m.lookup(ev.OrderID, ev.ClientOrderID)
+	if o == nil {
+		o = m.matchUnknown(ev.ClientOrderID, ev.Symbol, ev.Side, ev.Price, ev.Quantity, 0)
+	}
+	if o == nil {
+		o = m.track(&TrackedOrder{
+			OrderID:   ev.OrderID,
+			ClientID:  ev.ClientOrderID,
+			Symbol:    ev.Symbol,
+			Side:      ev.Side,
+			OrderType: ev.OrderType,
+			Status:    OrderPending,
+		})
+	}
+	m.index(o, ev.OrderID, ev.ClientOrderID)
+
+	newer := ev.EngineTime >= o.EngineTime
+	if newer {
+		o.EngineTime = ev.EngineTime
+		if !ev.Price.IsZero() {
+			o.Price = ev.Price
+		}
+		if !ev.TriggerPrice.IsZero() {
+			o.TriggerPrice = ev.TriggerPrice
+		}
+		if !ev.Quantity.IsZero() {
+			o.Quantity = ev.Quantity
+		}
+	}
+	if ev.TradeID != "" && o.OrderID != "" {
+		seen := m.trades[o.OrderID]
+		if seen == nil {
+			seen = make(map[string]bool)
+			m.trades[o.OrderID] = seen
+		}
+		if !seen[ev.TradeID] {
+			seen[ev.TradeID] = true
+			o.Fills++
+		}
+	}
+	m.advance(o, ev.OrderState, ev.ExecutedQty, ev.ExecutedQtyQ, newer)
+	o.UpdatedAt = m.now()
+	notify := m.changed(o)
+	m.mu.Unlock()
+
+	notify()
+}
+
+// Reconcile 用挂单列表校正本地状态：补充本地没有的挂单；
+// 本地未结束但已不在挂单列表中的订单查询订单历史得到最终状态；
+// 还没有 orderId 的 Unknown 订单在交易对最近的订单历史中查找，超过 unknownTimeout
+// 仍未找到时记为 Lost。最后删除结束超过 Retention 的订单。
+func (m *OrderManager) Reconcile(ctx context.Context) error {
+	m.mu.RLock()
+	symbols := make(map[string]bool)
+	for _, o := range m.orders {
+		if !o.Terminal() && o.Symbol != "" {
+			symbols[o.Symbol] = true
+		}
+	}
+	m.mu.RUnlock()
+	if len(symbols) == 0 {
+		symbols[""] = true
+	}
+
+	for _, symbol := range sortedSymbols(symbols) {
+		open, err := m.rest.GetTokenOpenAllOrders(ctx, symbol)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(open))
+		for _, order := range open {
+			seen[order.ID] = true
+			m.applyOrder(order, true)
+		}
+
+		// 不在挂单列表中的订单已经结束，刚更新过的可能还没出现在列表中
+		var missing []string
+		unknown := false
+		now := m.now()
+		m.mu.RLock()
+		for _, o := range m.orders {
+			if o.Terminal() || (symbol != "" && o.Symbol != symbol) || now.Sub(o.UpdatedAt) <= reconcileGrace {
+				continue
+			}
+			if o.OrderID == "" {
+				unknown = unknown || o.Status == OrderUnknown
+			} else if !seen[o.OrderID] {
+				missing = append(missing, o.OrderID)
+			}
+		}
+		m.mu.RUnlock()
+		for _, id := range missing {
+			history, err := m.rest.GetOrderHistory(ctx, backpack_interface.OrderHistory{OrderId: id, Limit: 1})
+			if err != nil {
+				return err
+			}
+			for _, order := range history {
+				m.applyOrder(order, false)
+			}
+		}
+		// Unknown 订单可能在两次对账之间已经成交，只会出现在订单历史中
+		if unknown && symbol != "" {
+			history, err := m.rest.GetOrderHistory(ctx, backpack_interface.OrderHistory{Symbol: symbol, Limit: unknownHistoryLimit})
+			if err != nil {
+				return err
+			}
+			for _, order := range history {
+				m.applyOrder(order, false)
+			}
+		}
+	}
+	m.expire()
+	return nil
+}
+
+// Run 每隔 interval 对账一次，直到 ctx 取消。单次对账失败不会退出，错误交给 onErr。
+func (m *OrderManager) Run(ctx context.Context, interval time.Duration, onErr func(error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("backpack websocket: reconcile interval must be positive, got %s", interval)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := m.Reconcile(ctx); err != nil && onErr != nil && ctx.Err() == nil {
+			onErr(err)
+		}
+	}
+}
+
+// applyOrder 合并 REST 返回的订单，create 为 false 时忽略本地没有记录的订单
+func (m *OrderManager) applyOrder(order backpack_interface.Order, create bool) {
+	m.mu.Lock()
+	clientID := clientIDString(order.ClientID)
+	o := m.lookup(order.ID, clientID)
+	if o == nil {
+		o = m.matchUnknown(clientID, order.Symbol, order.Side, order.Price, order.Quantity, order.CreatedAt)
+	}
+	if o == nil && !create {
+		m.mu.Unlock()
+		return
+	}
+	if o == nil {
+		o = m.track(&TrackedOrder{Status: OrderPending})
+	}
+	before := *o
+	m.mergeOrder(o, order)
+	var notify func()
+	if !sameOrder(before, *o) {
+		o.UpdatedAt = m.now()
+		notify = m.changed(o)
+	}
+	m.mu.Unlock()
+
+	if notify != nil {
+		notify()
+	}
+}
+
+// expire 将超时的 Unknown 订单记为 Lost，并删除结束超过 Retention 的订单
+func (m *OrderManager) expire() {
+	var notify []func()
+	m.mu.Lock()
+	now := m.now()
+	for _, o := range append([]*TrackedOrder(nil), m.orders...) {
+		switch {
+		case o.Status == OrderUnknown && now.Sub(o.UpdatedAt) > unknownTimeout:
+			o.Status = OrderLost
+			o.UpdatedAt = now
+			notify = append(notify, m.changed(o))
+		case o.Terminal() && m.Retention > 0 && now.Sub(o.UpdatedAt) > m.Retention:
+			m.remove(o)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, fn := range notify {
+		fn()
+	}
+}
+
+// mergeOrder 合并 REST 订单；REST 没有引擎时间，只能推进成交数量和状态
+func (m *OrderManager) mergeOrder(o *TrackedOrder, order backpack_interface.Order) {
+	m.index(o, order.ID, clientIDString(order.ClientID))
+	o.Symbol = order.Symbol
+	o.Side = order.Side
+	o.OrderType = order.OrderType
+	o.Price = order.Price
+	o.TriggerPrice = order.TriggerPrice
+	o.Quantity = order.Quantity
+	m.advance(o, order.Status, order.ExecutedQuantity, order.ExecutedQuoteQuantity, false)
+}
+
+// advance 推进状态和累计成交。newer 为 true 时接受任意非回退的状态，
+// 否则只接受更靠后的状态；结束的订单不会再改变状态。
+func (m *OrderManager) advance(o *TrackedOrder, status backpack_interface.OrderStatus, executed, executedQuote backpack_interface.Decimal, newer bool) {
+	if executed.Cmp(o.ExecutedQuantity) > 0 {
+		o.ExecutedQuantity = executed
+	}
+	if executedQuote.Cmp(o.ExecutedQuoteQuantity) > 0 {
+		o.ExecutedQuoteQuantity = executedQuote
+	}
+	if status == "" || o.Terminal() {
+		return
+	}
+	if rank := statusRank(status); rank > statusRank(o.Status) || (newer && rank == statusRank(o.Status)) {
+		o.Status = status
+		o.Err = nil
+	}
+}
+
+// track 记录新订单，必须持有写锁。clientId 属于已结束的订单时，由新订单接管 clientId 索引。
+func (m *OrderManager) track(o *TrackedOrder) *TrackedOrder {
+	if existing := m.lookup(o.OrderID, o.ClientID); existing != nil {
+		if !existing.Terminal() {
+			return existing
+		}
+		if o.ClientID != "" && m.byClient[o.ClientID] == existing {
+			delete(m.byClient, o.ClientID)
+		}
+	}
+	m.orders = append(m.orders, o)
+	m.index(o, o.OrderID, o.ClientID)
+	return o
+}
+
+// remove 删除订单记录，必须持有写锁
+func (m *OrderManager) remove(o *TrackedOrder) {
+	for i, v := range m.orders {
+		if v == o {
+			m.orders = append(m.orders[:i], m.orders[i+1:]...)
+			break
+		}
+	}
+	if o.OrderID != "" && m.byID[o.OrderID] == o {
+		delete(m.byID, o.OrderID)
+		delete(m.trades, o.OrderID)
+	}
+	if o.ClientID != "" && m.byClient[o.ClientID] == o {
+		delete(m.byClient, o.ClientID)
+	}
+	delete(m.watchers, o)
+}
+
+// index 补充订单的 orderId 和 clientId 索引，必须持有写锁。
+// clientId 已被另一个未结束的订单使用时不改变 clientId 索引。
+func (m *OrderManager) index(o *TrackedOrder, orderID, clientID string) {
+	if orderID != "" {
+		o.OrderID = orderID
+		m.byID[orderID] = o
+	}
+	if clientID != "" {
+		o.ClientID = clientID
+		if cur := m.byClient[clientID]; cur == nil || cur == o || cur.Terminal() {
+			m.byClient[clientID] = o
+		}
+	}
+}
+
+// lookup 查找推送或 REST 订单对应的记录，必须持有锁。
+// clientId 可能被复用，已有 orderId 的记录只按 orderId 匹配。
+func (m *OrderManager) lookup(orderID, clientID string) *TrackedOrder {
+	if o, ok := m.byID[orderID]; ok && orderID != "" {
+		return o
+	}
+	if o, ok := m.byClient[clientID]; ok && clientID != "" {
+		if orderID == "" || o.OrderID == "" {
+			return o
+		}
+	}
+	return nil
+}
+
+// find 按 orderId 或 clientId 查找订单，必须持有锁
+func (m *OrderManager) find(id string) *TrackedOrder {
+	if o, ok := m.byID[id]; ok {
+		return o
+	}
+	return m.byClient[id]
+}
+
+// matchUnknown 按内容查找还没有 orderId 的 Unknown 订单，必须持有锁。
+// createdAt 为交易所记录的下单时间（毫秒），早于本地发送时间超过 reconcileGrace 的不匹配，为 0 时不比较。
+func (m *OrderManager) matchUnknown(clientID, symbol string, side backpack_interface.Side,
+	price, quantity backpack_interface.Decimal, createdAt int64) *TrackedOrder {
+	for _, o := range m.orders {
+		if o.Status != OrderUnknown || o.OrderID != "" || o.ClientID != clientID || o.Symbol != symbol ||
+			o.Side != side || !o.Price.Equal(price) || !o.Quantity.Equal(quantity) {
+			continue
+		}
+		if createdAt != 0 && createdAt < o.SubmittedAt.Add(-reconcileGrace).UnixMilli() {
+			continue
+		}
+		return o
+	}
+	return nil
+}
+
+// changed 在持有写锁时取出需要通知的对象，返回的函数在锁外调用
+func (m *OrderManager) changed(o *TrackedOrder) func() {
+	snapshot := *o
+	handlers := append([]func(TrackedOrder){}, m.onChange...)
+	for _, ch := range m.watchers[o] {
+		select {
+		case ch <- snapshot:
+		default:
+			// 丢弃最旧的通知，保证最后一条是最新状态
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- snapshot
+		}
+	}
+	return func() {
+		for _, fn := range handlers {
+			fn(snapshot)
+		}
+	}
+}
+
+// sameOrder 比较对账前后的订单是否有变化
+func sameOrder(a, b TrackedOrder) bool {
+	return a.OrderID == b.OrderID &&
+		a.ClientID == b.ClientID &&
+		a.Status == b.Status &&
+		a.Price.Equal(b.Price) &&
+		a.TriggerPrice.Equal(b.TriggerPrice) &&
+		a.Quantity.Equal(b.Quantity) &&
+		a.ExecutedQuantity.Equal(b.ExecutedQuantity) &&
+		a.ExecutedQuoteQuantity.Equal(b.ExecutedQuoteQuantity)
+}
+
+// rejected 判断下单错误是否表示交易所确定没有接受订单
+func rejected(err error) bool {
+	if errors.Is(err, backpack_interface.ErrInvalidOrder) {
+		return true
+	}
+	var apiErr *backpack_interface.APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError
+}
+
+func clientIDString(id uint32) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// sortedSymbols 返回排序后的交易对，便于对账顺序稳定
+func sortedSymbols(symbols map[string]bool) []string {
+	rst := make([]string, 0, len(symbols))
+	for s := range symbols {
+		rst = append(rst, s)
+	}
+	sort.Strings(rst)
+	return rst
+}
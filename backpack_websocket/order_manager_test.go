@@ -0,0 +1,308 @@
+package backpack_websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backpack_api/backpack_interface"
+)
+
+func testOrder() backpack_interface.CreateOrder {
+	return backpack_interface.LimitOrder("SOL_USDC", backpack_interface.SideBid,
+		backpack_interface.MustDecimal("10"), backpack_interface.MustDecimal("2"), backpack_interface.ClientID(7))
+}
+
+func TestSubmitStatusOnError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   backpack_interface.OrderStatus
+	}{
+		{"bad request", http.StatusBadRequest, OrderRejected},
+		{"rate limited", http.StatusTooManyRequests, OrderRejected},
+		{"server error", http.StatusInternalServerError, OrderUnknown},
+		{"gateway timeout", http.StatusGatewayTimeout, OrderUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			o, err := NewOrderManager(rest).Submit(context.Background(), testOrder())
+			if err == nil {
+				t.Fatal("Submit: want error")
+			}
+			if o.Status != tt.want || o.Err == nil {
+				t.Fatalf("Status = %s, Err = %v; want %s with error", o.Status, o.Err, tt.want)
+			}
+			if tt.want == OrderUnknown && o.Terminal() {
+				t.Fatal("Unknown order must not be terminal")
+			}
+		})
+	}
+}
+
+func TestSubmitInvalidOrderRejected(t *testing.T) {
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	co := testOrder()
+	co.Side = "buy"
+	o, _ := NewOrderManager(rest).Submit(context.Background(), co)
+	if o.Status != OrderRejected {
+		t.Fatalf("Status = %s, want %s", o.Status, OrderRejected)
+	}
+}
+
+func TestUnknownOrderSettledByPush(t *testing.T) {
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	m := NewOrderManager(rest)
+	if o, _ := m.Submit(context.Background(), testOrder()); o.Status != OrderUnknown {
+		t.Fatalf("Status = %s, want %s", o.Status, OrderUnknown)
+	}
+
+	d := backpack_interface.MustDecimal
+	m.Apply(OrderUpdate{OrderID: "111", ClientOrderID: "7", OrderState: backpack_interface.OrderStatusPartiallyFilled,
+		ExecutedQty: d("1"), TradeID: "1", EngineTime: 100})
+	m.Apply(OrderUpdate{OrderID: "111", ClientOrderID: "7", OrderState: backpack_interface.OrderStatusFilled,
+		ExecutedQty: d("2"), TradeID: "2", EngineTime: 200})
+
+	o, ok := m.Get("111")
+	if !ok {
+		t.Fatal("order not found by orderId")
+	}
+	if o.Status != backpack_interface.OrderStatusFilled || !o.ExecutedQuantity.Equal(d("2")) || o.Err != nil {
+		t.Fatalf("got %s executed %s err %v, want Filled executed 2", o.Status, o.ExecutedQuantity, o.Err)
+	}
+	if len(m.Snapshot()) != 1 {
+		t.Fatalf("tracked %d orders, want 1", len(m.Snapshot()))
+	}
+}
+
+func TestUnknownOrderSettledByReconcile(t *testing.T) {
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/api/v1/orders":
+			w.Write([]byte(`[{"id":"111","clientId":7,"symbol":"SOL_USDC","side":"Bid","orderType":"Limit",` +
+				`"price":"10","quantity":"2","executedQuantity":"0.5","status":"PartiallyFilled"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	m := NewOrderManager(rest)
+	m.Submit(context.Background(), testOrder())
+	if err := m.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	o, ok := m.Get("7")
+	if !ok || o.OrderID != "111" || o.Status != backpack_interface.OrderStatusPartiallyFilled {
+		t.Fatalf("got %+v, want order 111 PartiallyFilled", o)
+	}
+}
+
+func TestOrderManagerRunInterval(t *testing.T) {
+	m := NewOrderManager(nil)
+	if err := m.Run(context.Background(), 0, nil); err == nil {
+		t.Fatal("Run with zero interval: want error")
+	}
+}
+
+// setClock 让 m 使用可推进的时钟，返回推进时钟的函数
+func setClock(m *OrderManager) func(time.Duration) {
+	now := time.Unix(1700000000, 0)
+	m.now = func() time.Time { return now }
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestClientIDReuse(t *testing.T) {
+	ids := []string{"111", "222"}
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":%q,"clientId":7,"symbol":"SOL_USDC","side":"Bid","orderType":"Limit",`+
+			`"price":"10","quantity":"2","status":"New"}`, ids[0])
+		ids = ids[1:]
+	})
+	m := NewOrderManager(rest)
+	ctx := context.Background()
+	d := backpack_interface.MustDecimal
+	if _, err := m.Submit(ctx, testOrder()); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	m.Apply(OrderUpdate{OrderID: "111", ClientOrderID: "7", OrderState: backpack_interface.OrderStatusFilled,
+		ExecutedQty: d("2"), TradeID: "1", EngineTime: 100})
+
+	o, err := m.Submit(ctx, testOrder())
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if o.OrderID != "222" || o.Status != backpack_interface.OrderStatusNew || !o.ExecutedQuantity.IsZero() {
+		t.Fatalf("second order = %+v, want 222 New", o)
+	}
+	// 旧订单的迟到推送不能影响新订单
+	m.Apply(OrderUpdate{OrderID: "111", ClientOrderID: "7", OrderState: backpack_interface.OrderStatusFilled,
+		ExecutedQty: d("2"), EngineTime: 50})
+	m.Apply(OrderUpdate{OrderID: "222", ClientOrderID: "7", OrderState: backpack_interface.OrderStatusPartiallyFilled,
+		ExecutedQty: d("1"), TradeID: "1", EngineTime: 300})
+
+	old, _ := m.Get("111")
+	cur, _ := m.Get("7")
+	if old.OrderID != "111" || old.Status != backpack_interface.OrderStatusFilled {
+		t.Errorf("old order = %+v, want 111 Filled", old)
+	}
+	if cur.OrderID != "222" || cur.Status != backpack_interface.OrderStatusPartiallyFilled ||
+		!cur.ExecutedQuantity.Equal(d("1")) || cur.Fills != 1 {
+		t.Errorf("clientId 7 = %+v, want 222 PartiallyFilled with 1 fill", cur)
+	}
+	if n := len(m.Open()); n != 1 {
+		t.Errorf("open = %d, want 1", n)
+	}
+}
+
+func TestUnknownOrderWithoutClientIDMatchedByPush(t *testing.T) {
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+	})
+	m := NewOrderManager(rest)
+	d := backpack_interface.MustDecimal
+	co := backpack_interface.LimitOrder("SOL_USDC", backpack_interface.SideBid, d("10"), d("2"))
+	if o, _ := m.Submit(context.Background(), co); o.Status != OrderUnknown {
+		t.Fatalf("Status = %s, want %s", o.Status, OrderUnknown)
+	}
+
+	// 内容不同的推送是另一个订单
+	m.Apply(OrderUpdate{OrderID: "99", Symbol: "SOL_USDC", Side: backpack_interface.SideBid, Price: d("10.5"),
+		Quantity: d("2"), OrderState: backpack_interface.OrderStatusNew, EngineTime: 50})
+	m.Apply(OrderUpdate{OrderID: "111", Symbol: "SOL_USDC", Side: backpack_interface.SideBid, Price: d("10"),
+		Quantity: d("2"), OrderState: backpack_interface.OrderStatusFilled, ExecutedQty: d("2"), EngineTime: 100})
+
+	if n := len(m.Snapshot()); n != 2 {
+		t.Fatalf("tracked %d orders, want 2", n)
+	}
+	o, ok := m.Get("111")
+	if !ok || o.Status != backpack_interface.OrderStatusFilled || o.SubmittedAt.IsZero() || o.Err != nil {
+		t.Fatalf("order 111 = %+v, want the submitted order Filled", o)
+	}
+	if open := m.Open(); len(open) != 1 || open[0].OrderID != "99" {
+		t.Fatalf("open = %+v, want only order 99", open)
+	}
+}
+
+func TestUnknownOrderFoundInHistory(t *testing.T) {
+	var historyQuery string
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == "/api/v1/orders":
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/wapi/v1/history/orders":
+			historyQuery = r.URL.RawQuery
+			w.Write([]byte(`[{"id":"111","clientId":7,"symbol":"SOL_USDC","side":"Bid","orderType":"Limit",` +
+				`"price":"10","quantity":"2","executedQuantity":"2","status":"Filled","createdAt":1700000000500}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	m := NewOrderManager(rest)
+	advance := setClock(m)
+	ctx := context.Background()
+	m.Submit(ctx, testOrder())
+
+	// 宽限期内不查询订单历史
+	if err := m.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if historyQuery != "" {
+		t.Fatalf("history queried within grace period: %s", historyQuery)
+	}
+	advance(2 * reconcileGrace)
+	if err := m.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !strings.Contains(historyQuery, "symbol=SOL_USDC") {
+		t.Errorf("history query = %q, want symbol=SOL_USDC", historyQuery)
+	}
+	o, _ := m.Get("7")
+	if o.OrderID != "111" || o.Status != backpack_interface.OrderStatusFilled {
+		t.Fatalf("got %+v, want order 111 Filled", o)
+	}
+}
+
+func TestUnknownOrderExpires(t *testing.T) {
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/api/v1/orders":
+			w.Write([]byte(`[]`))
+		default:
+			// 订单历史中只有提交之前的同样内容的订单
+			w.Write([]byte(`[{"id":"5","symbol":"SOL_USDC","side":"Bid","orderType":"Limit",` +
+				`"price":"10","quantity":"2","status":"Filled","createdAt":1699999000000}]`))
+		}
+	})
+	m := NewOrderManager(rest)
+	advance := setClock(m)
+	ctx := context.Background()
+	d := backpack_interface.MustDecimal
+	m.Submit(ctx, backpack_interface.LimitOrder("SOL_USDC", backpack_interface.SideBid, d("10"), d("2")))
+
+	advance(unknownTimeout / 2)
+	if err := m.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if open := m.Open(); len(open) != 1 || open[0].Status != OrderUnknown || open[0].OrderID != "" {
+		t.Fatalf("open = %+v, want the Unknown order unmatched", open)
+	}
+	advance(unknownTimeout)
+	if err := m.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	snap := m.Snapshot()
+	if len(snap) != 1 || snap[0].Status != OrderLost || !snap[0].Terminal() || snap[0].Err == nil {
+		t.Fatalf("snapshot = %+v, want one Lost order", snap)
+	}
+	if n := len(m.Open()); n != 0 {
+		t.Fatalf("open = %d, want 0", n)
+	}
+}
+
+func TestOrderManagerRetention(t *testing.T) {
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	m := NewOrderManager(rest)
+	advance := setClock(m)
+	d := backpack_interface.MustDecimal
+	m.Apply(OrderUpdate{OrderID: "111", ClientOrderID: "7", Symbol: "SOL_USDC",
+		OrderState: backpack_interface.OrderStatusFilled, ExecutedQty: d("2"), TradeID: "1", EngineTime: 100})
+	m.Apply(OrderUpdate{OrderID: "222", Symbol: "SOL_USDC", OrderState: backpack_interface.OrderStatusNew, EngineTime: 100})
+
+	advance(m.Retention / 2)
+	m.Reconcile(context.Background())
+	if n := len(m.Snapshot()); n != 2 {
+		t.Fatalf("tracked %d orders before retention, want 2", n)
+	}
+	advance(m.Retention)
+	m.Reconcile(context.Background())
+	if _, ok := m.Get("111"); ok {
+		t.Error("finished order 111 not evicted")
+	}
+	if _, ok := m.Get("7"); ok {
+		t.Error("clientId 7 still indexed")
+	}
+	if len(m.trades) != 0 {
+		t.Errorf("trades = %v, want empty", m.trades)
+	}
+	// 未结束的订单保留
+	if snap := m.Snapshot(); len(snap) != 1 || snap[0].OrderID != "222" {
+		t.Errorf("snapshot = %+v, want only order 222", snap)
+	}
+}
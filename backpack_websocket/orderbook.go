@@ -0,0 +1,313 @@
+package backpack_websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"backpack_api/backpack_interface"
+)
+
+// 订单簿同步参数
+const (
+	orderBookBufferSize  = 1000            // 等待快照时最多缓存的增量数
+	orderBookResyncDelay = 1 * time.Second // 快照加载失败后的重试间隔
+)
+
+// errSnapshotStale 表示快照早于缓存的第一条增量，需要重新加载
+var errSnapshotStale = errors.New("depth snapshot is older than buffered updates")
+
+// OrderBookStats 为订单簿同步的状态
+type OrderBookStats struct {
+	Synced       bool      // 快照已加载且增量连续
+	LastUpdateID int64     // 最后应用的更新 ID
+	Resyncs      uint64    // 因序号不连续而重新同步的次数
+	LastSync     time.Time // 最近一次加载快照成功的时间
+	LastErr      error     // 最近一次加载快照的错误，成功后清空
+}
+
+// OrderBook 由 REST 深度快照和 depth 流的增量维护的本地订单簿。
+// 加载快照期间收到的增量先缓存，快照加载后按更新 ID 重放；
+// 发现序号不连续时自动重新加载快照。查询方法可以并发调用。
+type OrderBook struct {
+	symbol string
+	rest   *backpack_interface.Client
+	resync chan struct{}
+
+	mu     sync.RWMutex
+	bids   []backpack_interface.PriceLevel // 价格从高到低
+	asks   []backpack_interface.PriceLevel // 价格从低到高
+	buffer []DepthEvent
+	stats  OrderBookStats
+}
+
+// NewOrderBook 创建 symbol 的订单簿，在 ws 上注册 depth 处理函数并订阅 depth.<symbol>，
+// 随后在后台加载快照，直到 ctx 取消。ws 的 ListenAndServe 需要另外运行。
+func NewOrderBook(
+	ctx context.Context,
+	ws *WebSocketClient,
+	rest *backpack_interface.Client,
+	symbol string,
+) (*OrderBook, error) {
+	ob := &OrderBook{
+		symbol: symbol,
+		rest:   rest,
+		resync: make(chan struct{}, 1),
+	}
+	ws.OnDepth(symbol, ob.apply)
+	if err := ws.Subscribe(ctx, "depth."+symbol); err != nil {
+		return nil, err
+	}
+	ob.requestResync()
+	go ob.run(ctx)
+	return ob, nil
+}
+
+// Symbol 返回订单簿的交易对
+func (ob *OrderBook) Symbol() string {
+	return ob.symbol
+}
+
+// Stats 返回同步状态的快照
+func (ob *OrderBook) Stats() OrderBookStats {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.stats
+}
+
+// BestBid 返回最高买价，订单簿为空时 ok 为 false
+func (ob *OrderBook) BestBid() (level backpack_interface.PriceLevel, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	if len(ob.bids) == 0 {
+		return level, false
+	}
+	return ob.bids[0], true
+}
+
+// BestAsk 返回最低卖价，订单簿为空时 ok 为 false
+func (ob *OrderBook) BestAsk() (level backpack_interface.PriceLevel, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	if len(ob.asks) == 0 {
+		return level, false
+	}
+	return ob.asks[0], true
+}
+
+// Mid 返回最优买卖价的中间价，任意一边为空时 ok 为 false
+func (ob *OrderBook) Mid() (mid backpack_interface.Decimal, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	if len(ob.bids) == 0 || len(ob.asks) == 0 {
+		return mid, false
+	}
+	sum := ob.bids[0].Price.Add(ob.asks[0].Price)
+	return sum.Quo(backpack_interface.DecimalFromInt(2), sum.Scale()+1), true
+}
+
+// Bids 返回价格最高的 n 档买单，n <= 0 时返回全部
+func (ob *OrderBook) Bids(n int) []backpack_interface.PriceLevel {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return top(ob.bids, n)
+}
+
+// Asks 返回价格最低的 n 档卖单，n <= 0 时返回全部
+func (ob *OrderBook) Asks(n int) []backpack_interface.PriceLevel {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return top(ob.asks, n)
+}
+
+// BidDepth 返回价格不低于 price 的买单累计数量
+func (ob *OrderBook) BidDepth(price backpack_interface.Decimal) backpack_interface.Decimal {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	var total backpack_interface.Decimal
+	for _, l := range ob.bids {
+		if l.Price.Cmp(price) < 0 {
+			break
+		}
+		total = total.Add(l.Quantity)
+	}
+	return total
+}
+
+// AskDepth 返回价格不高于 price 的卖单累计数量
+func (ob *OrderBook) AskDepth(price backpack_interface.Decimal) backpack_interface.Decimal {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	var total backpack_interface.Decimal
+	for _, l := range ob.asks {
+		if l.Price.Cmp(price) > 0 {
+			break
+		}
+		total = total.Add(l.Quantity)
+	}
+	return total
+}
+
+func top(levels []backpack_interface.PriceLevel, n int) []backpack_interface.PriceLevel {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	return append([]backpack_interface.PriceLevel(nil), levels[:n]...)
+}
+
+// apply 处理一条深度增量，未同步时缓存，序号不连续时触发重新同步
+func (ob *OrderBook) apply(ev DepthEvent) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if !ob.stats.Synced {
+		ob.bufferEvent(ev)
+		return
+	}
+	if ev.LastUpdateID <= ob.stats.LastUpdateID {
+		return
+	}
+	if ev.FirstUpdateID > ob.stats.LastUpdateID+1 {
+		ob.stats.Synced = false
+		ob.stats.Resyncs++
+		ob.buffer = []DepthEvent{ev}
+		ob.requestResync()
+		return
+	}
+	ob.applyLevels(ev)
+}
+
+func (ob *OrderBook) bufferEvent(ev DepthEvent) {
+	if len(ob.buffer) >= orderBookBufferSize {
+		ob.buffer = ob.buffer[1:]
+	}
+	ob.buffer = append(ob.buffer, ev)
+}
+
+func (ob *OrderBook) applyLevels(ev DepthEvent) {
+	for _, l := range ev.Bids {
+		ob.bids = setLevel(ob.bids, l, true)
+	}
+	for _, l := range ev.Asks {
+		ob.asks = setLevel(ob.asks, l, false)
+	}
+	ob.stats.LastUpdateID = ev.LastUpdateID
+}
+
+// setLevel 更新有序档位，数量为 0 时删除；desc 为 true 时按价格从高到低排列
+func setLevel(levels []backpack_interface.PriceLevel, l backpack_interface.PriceLevel, desc bool) []backpack_interface.PriceLevel {
+	i := sort.Search(len(levels), func(i int) bool {
+		c := levels[i].Price.Cmp(l.Price)
+		if desc {
+			return c <= 0
+		}
+		return c >= 0
+	})
+	found := i < len(levels) && levels[i].Price.Equal(l.Price)
+	switch {
+	case l.Quantity.IsZero():
+		if found {
+			levels = append(levels[:i], levels[i+1:]...)
+		}
+	case found:
+		levels[i].Quantity = l.Quantity
+	default:
+		levels = append(levels, backpack_interface.PriceLevel{})
+		copy(levels[i+1:], levels[i:])
+		levels[i] = l
+	}
+	return levels
+}
+
+func (ob *OrderBook) requestResync() {
+	select {
+	case ob.resync <- struct{}{}:
+	default:
+	}
+}
+
+// run 在后台处理重新同步请求，直到 ctx 取消
+func (ob *OrderBook) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ob.resync:
+		}
+		for ob.sync(ctx) != nil {
+			timer := time.NewTimer(orderBookResyncDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// sync 加载快照并重放缓存的增量
+func (ob *OrderBook) sync(ctx context.Context) error {
+	depth, err := ob.rest.GetDepth(ctx, ob.symbol)
+	if err == nil {
+		err = ob.load(depth)
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.stats.LastErr = err
+	if err == nil {
+		ob.stats.LastSync = time.Now()
+	}
+	return err
+}
+
+// load 用快照替换订单簿并重放缓存中更新 ID 晚于快照的增量
+func (ob *OrderBook) load(depth *backpack_interface.Depth) error {
+	lastID, err := strconv.ParseInt(depth.LastUpdateID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("depth snapshot %s: lastUpdateId %q: %w", ob.symbol, depth.LastUpdateID, err)
+	}
+
+	bids := append([]backpack_interface.PriceLevel(nil), depth.Bids...)
+	asks := append([]backpack_interface.PriceLevel(nil), depth.Asks...)
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Price.Cmp(bids[j].Price) > 0 })
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Price.Cmp(asks[j].Price) < 0 })
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if ob.stats.Synced {
+		return nil
+	}
+
+	// 丢弃快照已经包含的增量，剩下的第一条必须与快照衔接
+	pending := ob.buffer[:0]
+	for _, ev := range ob.buffer {
+		if ev.LastUpdateID > lastID {
+			pending = append(pending, ev)
+		}
+	}
+	ob.buffer = pending
+	if len(pending) > 0 && pending[0].FirstUpdateID > lastID+1 {
+		return errSnapshotStale
+	}
+
+	ob.bids, ob.asks = bids, asks
+	ob.stats.LastUpdateID = lastID
+	for i, ev := range pending {
+		if ev.FirstUpdateID > ob.stats.LastUpdateID+1 {
+			// 缓存中间有缺口，只能再取一次快照
+			ob.buffer = pending[i:]
+			ob.stats.Resyncs++
+			return fmt.Errorf("depth %s: gap in buffered updates at %d", ob.symbol, ev.FirstUpdateID)
+		}
+		ob.applyLevels(ev)
+	}
+	ob.buffer = nil
+	ob.stats.Synced = true
+	return nil
+}
@@ -0,0 +1,184 @@
+package backpack_websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backpack_api/backpack_interface"
+)
+
+// newTestREST 创建指向 handler 的 REST 客户端，不重试、不按市场限制校验
+func newTestREST(t *testing.T, handler http.HandlerFunc) *backpack_interface.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	rest, err := backpack_interface.NewClient(backpack_interface.Key{APIKey: testAPIKey, Secret: testSecret})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	rest.BaseURL = srv.URL
+	rest.Retry = nil
+	rest.Markets = nil
+	return rest
+}
+
+func newTestOrderBook() *OrderBook {
+	return &OrderBook{symbol: "SOL_USDC", resync: make(chan struct{}, 1)}
+}
+
+func level(price, quantity string) backpack_interface.PriceLevel {
+	return backpack_interface.PriceLevel{
+		Price:    backpack_interface.MustDecimal(price),
+		Quantity: backpack_interface.MustDecimal(quantity),
+	}
+}
+
+func depthEvent(first, last int64, bids, asks []backpack_interface.PriceLevel) DepthEvent {
+	return DepthEvent{Symbol: "SOL_USDC", FirstUpdateID: first, LastUpdateID: last, Bids: bids, Asks: asks}
+}
+
+func snapshot(lastID int64) *backpack_interface.Depth {
+	return &backpack_interface.Depth{
+		Bids:         []backpack_interface.PriceLevel{level("9", "1"), level("10", "2")},
+		Asks:         []backpack_interface.PriceLevel{level("12", "1"), level("11", "3")},
+		LastUpdateID: fmt.Sprint(lastID),
+	}
+}
+
+func loadedOrderBook(t *testing.T, lastID int64) *OrderBook {
+	t.Helper()
+	ob := newTestOrderBook()
+	if err := ob.load(snapshot(lastID)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return ob
+}
+
+func assertBest(t *testing.T, ob *OrderBook, bid, ask string) {
+	t.Helper()
+	b, ok := ob.BestBid()
+	if !ok || !b.Price.Equal(backpack_interface.MustDecimal(bid)) {
+		t.Errorf("BestBid = %v %v, want %s", b.Price, ok, bid)
+	}
+	a, ok := ob.BestAsk()
+	if !ok || !a.Price.Equal(backpack_interface.MustDecimal(ask)) {
+		t.Errorf("BestAsk = %v %v, want %s", a.Price, ok, ask)
+	}
+}
+
+func TestOrderBookBuffersUntilSnapshot(t *testing.T) {
+	ob := newTestOrderBook()
+	ob.apply(depthEvent(99, 100, []backpack_interface.PriceLevel{level("10.5", "1")}, nil))  // 快照已包含
+	ob.apply(depthEvent(101, 102, []backpack_interface.PriceLevel{level("10", "0")}, nil))   // 删除 10
+	ob.apply(depthEvent(103, 103, nil, []backpack_interface.PriceLevel{level("10.8", "4")})) // 新增卖价
+	if ob.Stats().Synced {
+		t.Fatal("synced before snapshot")
+	}
+	if _, ok := ob.BestBid(); ok {
+		t.Fatal("book not empty before snapshot")
+	}
+
+	if err := ob.load(snapshot(100)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	st := ob.Stats()
+	if !st.Synced || st.LastUpdateID != 103 {
+		t.Fatalf("Stats = %+v, want synced at 103", st)
+	}
+	assertBest(t, ob, "9", "10.8")
+}
+
+func TestOrderBookStaleSnapshot(t *testing.T) {
+	ob := newTestOrderBook()
+	ob.apply(depthEvent(105, 106, nil, nil))
+	if err := ob.load(snapshot(100)); !errors.Is(err, errSnapshotStale) {
+		t.Fatalf("load = %v, want errSnapshotStale", err)
+	}
+	if ob.Stats().Synced {
+		t.Fatal("synced from stale snapshot")
+	}
+}
+
+func TestOrderBookOverlapAfterSync(t *testing.T) {
+	ob := loadedOrderBook(t, 100)
+	ob.apply(depthEvent(99, 101, []backpack_interface.PriceLevel{level("10.5", "1")}, nil))
+	st := ob.Stats()
+	if !st.Synced || st.Resyncs != 0 || st.LastUpdateID != 101 {
+		t.Fatalf("Stats = %+v, want synced at 101 without resync", st)
+	}
+	assertBest(t, ob, "10.5", "11")
+
+	// 已经应用过的增量被忽略
+	ob.apply(depthEvent(100, 101, []backpack_interface.PriceLevel{level("10.5", "0")}, nil))
+	assertBest(t, ob, "10.5", "11")
+}
+
+func TestOrderBookGapTriggersResync(t *testing.T) {
+	ob := loadedOrderBook(t, 100)
+	ob.apply(depthEvent(101, 101, nil, nil))
+	select {
+	case <-ob.resync:
+		t.Fatal("resync requested after normal update")
+	default:
+	}
+
+	ob.apply(depthEvent(105, 106, []backpack_interface.PriceLevel{level("10.2", "1")}, nil))
+	st := ob.Stats()
+	if st.Synced || st.Resyncs != 1 {
+		t.Fatalf("Stats = %+v, want unsynced with 1 resync", st)
+	}
+	select {
+	case <-ob.resync:
+	default:
+		t.Fatal("gap did not request resync")
+	}
+
+	// 缺口期间的增量继续缓存，新快照加载后重放
+	ob.apply(depthEvent(107, 107, nil, []backpack_interface.PriceLevel{level("11", "0")}))
+	if err := ob.load(snapshot(104)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	st = ob.Stats()
+	if !st.Synced || st.LastUpdateID != 107 {
+		t.Fatalf("Stats = %+v, want synced at 107", st)
+	}
+	assertBest(t, ob, "10.2", "12")
+}
+
+func TestOrderBookGapInBuffer(t *testing.T) {
+	ob := newTestOrderBook()
+	ob.apply(depthEvent(101, 101, nil, nil))
+	ob.apply(depthEvent(104, 104, nil, nil))
+	if err := ob.load(snapshot(100)); err == nil {
+		t.Fatal("load with gap in buffer: want error")
+	}
+	if st := ob.Stats(); st.Synced || st.Resyncs != 1 {
+		t.Fatalf("Stats = %+v, want unsynced with 1 resync", st)
+	}
+}
+
+func TestOrderBookSyncFromREST(t *testing.T) {
+	var lastID int64 = 100
+	rest := newTestREST(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/depth" || r.URL.Query().Get("symbol") != "SOL_USDC" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		fmt.Fprintf(w, `{"bids":[["10","2"]],"asks":[["11","3"]],"lastUpdateId":"%d"}`, lastID)
+	})
+	ob := newTestOrderBook()
+	ob.rest = rest
+	ob.apply(depthEvent(101, 101, []backpack_interface.PriceLevel{level("10.1", "1")}, nil))
+
+	if err := ob.sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	st := ob.Stats()
+	if !st.Synced || st.LastUpdateID != 101 || st.LastSync.IsZero() || st.LastErr != nil {
+		t.Fatalf("Stats = %+v, want synced at 101", st)
+	}
+	assertBest(t, ob, "10.1", "11")
+}
@@ -0,0 +1,249 @@
+package backpack_websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 连接池的默认参数
+const (
+	DefaultPoolSize        = 4  // 连接数
+	DefaultPoolStreamLimit = 50 // 每个连接最多订阅的流数
+	poolEventBuffer        = 1024
+)
+
+// ErrPoolFull 表示所有连接都已达到流数上限
+var ErrPoolFull = errors.New("backpack websocket: all pool connections are at the stream limit")
+
+// Event 为连接池合并输出的一条推送
+type Event struct {
+	Stream string          // 流名称，例如 depth.SOL_USDC
+	Data   json.RawMessage // 原始的 data 字段
+	Conn   int             // 收到该消息的连接序号
+}
+
+// Decode 按流名称把 Data 解码为 DepthEvent、TradeEvent、TickerEvent、BookTickerEvent、KlineEvent 或 OrderUpdate
+func (ev Event) Decode() (interface{}, error) {
+	switch strings.SplitN(ev.Stream, ".", 2)[0] {
+	case "depth":
+		return decodeAs[DepthEvent](ev)
+	case "trade":
+		return decodeAs[TradeEvent](ev)
+	case "ticker":
+		return decodeAs[TickerEvent](ev)
+	case "bookTicker":
+		return decodeAs[BookTickerEvent](ev)
+	case "kline":
+		return decodeAs[KlineEvent](ev)
+	case "account":
+		if strings.HasPrefix(ev.Stream, "account.orderUpdate") {
+			return decodeAs[OrderUpdate](ev)
+		}
+	}
+	return nil, fmt.Errorf("decode %s: unknown stream", ev.Stream)
+}
+
+func decodeAs[T any](ev Event) (interface{}, error) {
+	var v T
+	if err := json.Unmarshal(ev.Data, &v); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", ev.Stream, err)
+	}
+	return v, nil
+}
+
+// Pool 把订阅分散到多个 WebSocket 连接上，每个连接不超过 streamLimit 个流，
+// 所有连接收到的推送合并到 Events 通道。每次 Subscribe 的流按连接分组，
+// 同一连接的流在一条 SUBSCRIBE 消息中发送。
+type Pool struct {
+	clients []*WebSocketClient
+	limit   int
+	events  chan Event
+
+	mu      sync.Mutex
+	owner   map[string]int // 流 -> 连接序号
+	counts  []int          // 每个连接的流数
+	dropped uint64
+
+	closeEvents sync.Once
+}
+
+// NewPool 创建 size 个连接的连接池，size 或 streamLimit 不大于 0 时使用默认值。
+// opts 应用于每个连接；连接池会覆盖 WithMessageHandler。
+func NewPool(size, streamLimit int, opts ...Option) *Pool {
+	if size <= 0 {
+		size = DefaultPoolSize
+	}
+	if streamLimit <= 0 {
+		streamLimit = DefaultPoolStreamLimit
+	}
+	p := &Pool{
+		limit:  streamLimit,
+		events: make(chan Event, poolEventBuffer),
+		owner:  make(map[string]int),
+		counts: make([]int, size),
+	}
+	for i := 0; i < size; i++ {
+		conn := i
+		handler := WithMessageHandler(func(message []byte) { p.deliver(conn, message) })
+		p.clients = append(p.clients, NewWebSocketClient(append(opts, handler)...))
+	}
+	return p
+}
+
+// Events 返回合并后的推送通道，ListenAndServe 返回后关闭。
+// 通道满时丢弃新消息并计入 Dropped，避免阻塞读循环导致连接超时。
+func (p *Pool) Events() <-chan Event {
+	return p.events
+}
+
+// Dropped 返回因通道满而丢弃的消息数
+func (p *Pool) Dropped() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dropped
+}
+
+// Clients 返回池中的连接，可用于查看 Stats 或注册 Dispatcher 处理函数
+func (p *Pool) Clients() []*WebSocketClient {
+	return append([]*WebSocketClient(nil), p.clients...)
+}
+
+// Assignments 返回每个流所在的连接序号
+func (p *Pool) Assignments() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rst := make(map[string]int, len(p.owner))
+	for stream, conn := range p.owner {
+		rst[stream] = conn
+	}
+	return rst
+}
+
+func (p *Pool) deliver(conn int, message []byte) {
+	env, err := decodeEnvelope(message)
+	if err != nil || env.Stream == "" {
+		return
+	}
+	select {
+	case p.events <- Event{Stream: env.Stream, Data: env.Data, Conn: conn}:
+	default:
+		p.mu.Lock()
+		p.dropped++
+		p.mu.Unlock()
+	}
+}
+
+// Subscribe 订阅一个或多个流，新流分配给流数最少的连接。
+// 剩余容量不足以容纳所有新流时不订阅任何流并返回 ErrPoolFull。
+func (p *Pool) Subscribe(ctx context.Context, streams ...string) error {
+	p.mu.Lock()
+	counts := append([]int(nil), p.counts...)
+	assign := make(map[string]int)
+	for _, stream := range streams {
+		if _, ok := p.owner[stream]; ok {
+			continue
+		}
+		if _, ok := assign[stream]; ok {
+			continue
+		}
+		conn := 0
+		for i, n := range counts {
+			if n < counts[conn] {
+				conn = i
+			}
+		}
+		if counts[conn] >= p.limit {
+			p.mu.Unlock()
+			return ErrPoolFull
+		}
+		counts[conn]++
+		assign[stream] = conn
+	}
+	p.counts = counts
+	for stream, conn := range assign {
+		p.owner[stream] = conn
+	}
+	p.mu.Unlock()
+
+	return p.each(ctx, groupByConn(streams, assign), (*WebSocketClient).Subscribe)
+}
+
+// Unsubscribe 取消订阅一个或多个流
+func (p *Pool) Unsubscribe(ctx context.Context, streams ...string) error {
+	p.mu.Lock()
+	assign := make(map[string]int)
+	for _, stream := range streams {
+		if conn, ok := p.owner[stream]; ok {
+			assign[stream] = conn
+			p.counts[conn]--
+			delete(p.owner, stream)
+		}
+	}
+	p.mu.Unlock()
+
+	return p.each(ctx, groupByConn(streams, assign), (*WebSocketClient).Unsubscribe)
+}
+
+// groupByConn 按连接分组，保持 streams 中的顺序
+func groupByConn(streams []string, assign map[string]int) map[int][]string {
+	groups := make(map[int][]string)
+	for _, stream := range streams {
+		if conn, ok := assign[stream]; ok && !contains(groups[conn], stream) {
+			groups[conn] = append(groups[conn], stream)
+		}
+	}
+	return groups
+}
+
+func (p *Pool) each(
+	ctx context.Context,
+	groups map[int][]string,
+	fn func(*WebSocketClient, context.Context, ...string) error,
+) error {
+	var errs []error
+	for conn, streams := range groups {
+		if err := fn(p.clients[conn], ctx, streams...); err != nil {
+			errs = append(errs, fmt.Errorf("conn %d: %w", conn, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListenAndServe 运行所有连接，直到 ctx 取消或 Close，返回各连接的错误并关闭 Events
+func (p *Pool) ListenAndServe(ctx context.Context) error {
+	errs := make([]error, len(p.clients))
+	var wg sync.WaitGroup
+	for i, c := range p.clients {
+		wg.Add(1)
+		go func(i int, c *WebSocketClient) {
+			defer wg.Done()
+			if err := c.ListenAndServe(ctx); err != nil {
+				errs[i] = fmt.Errorf("conn %d: %w", i, err)
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	p.closeEvents.Do(func() { close(p.events) })
+	return errors.Join(errs...)
+}
+
+// Close 并发关闭所有连接
+func (p *Pool) Close(ctx context.Context) error {
+	errs := make([]error, len(p.clients))
+	var wg sync.WaitGroup
+	for i, c := range p.clients {
+		wg.Add(1)
+		go func(i int, c *WebSocketClient) {
+			defer wg.Done()
+			if err := c.Close(ctx); err != nil {
+				errs[i] = fmt.Errorf("conn %d: %w", i, err)
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
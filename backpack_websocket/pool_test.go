@@ -0,0 +1,142 @@
+package backpack_websocket
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"websocket-main"
+)
+
+func TestPoolAssignment(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(2, 3)
+
+	// 新流分配给流数最少的连接，相同时取序号小的
+	if err := p.Subscribe(ctx, "a", "b", "c", "a"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	want := map[string]int{"a": 0, "b": 1, "c": 0}
+	if got := p.Assignments(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Assignments = %v, want %v", got, want)
+	}
+	if got := p.Clients()[0].Subscriptions(); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("conn 0 subscriptions = %v", got)
+	}
+
+	// 剩余 3 个名额，放不下 4 个新流时一个都不订阅
+	if err := p.Subscribe(ctx, "d", "e", "f", "g"); !errors.Is(err, ErrPoolFull) {
+		t.Fatalf("Subscribe = %v, want ErrPoolFull", err)
+	}
+	if got := p.Assignments(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Assignments after ErrPoolFull = %v, want %v", got, want)
+	}
+	if got := p.Clients()[1].Subscriptions(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("conn 1 subscriptions after ErrPoolFull = %v", got)
+	}
+
+	// 已订阅的流不占用名额
+	if err := p.Subscribe(ctx, "a", "d", "e", "f"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := p.Subscribe(ctx, "g"); !errors.Is(err, ErrPoolFull) {
+		t.Fatalf("Subscribe on full pool = %v, want ErrPoolFull", err)
+	}
+
+	// 取消订阅后释放名额
+	if err := p.Unsubscribe(ctx, "b", "unknown"); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if err := p.Subscribe(ctx, "g"); err != nil {
+		t.Fatalf("Subscribe after Unsubscribe: %v", err)
+	}
+	if got := p.Assignments()["g"]; got != 1 {
+		t.Errorf("g assigned to conn %d, want 1", got)
+	}
+}
+
+func TestPoolBatchesAndMergesEvents(t *testing.T) {
+	url, conns := newTestServer(t)
+	p := NewPool(2, 10, WithURL(url))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+
+	if err := p.Subscribe(ctx, "depth.SOL_USDC", "trade.SOL_USDC", "depth.BTC_USDC", "trade.BTC_USDC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	go func() { done <- p.ListenAndServe(ctx) }()
+
+	// 每个连接用一条消息订阅分配给它的流
+	var got []string
+	var servers []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		conn := accept(t, conns)
+		msg := expectMessage(t, conn)
+		if msg.Method != "SUBSCRIBE" || len(msg.Params) != 2 {
+			t.Fatalf("got %s %v, want SUBSCRIBE with 2 streams", msg.Method, msg.Params)
+		}
+		got = append(got, msg.Params...)
+		servers = append(servers, conn)
+	}
+	sort.Strings(got)
+	if want := []string{"depth.BTC_USDC", "depth.SOL_USDC", "trade.BTC_USDC", "trade.SOL_USDC"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("subscribed %v, want %v", got, want)
+	}
+
+	// 推送合并到 Events，订阅确认等没有流名称的消息被忽略
+	servers[0].WriteMessage(websocket.TextMessage, []byte(`{"result":null,"id":1}`))
+	for _, conn := range servers {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"trade.SOL_USDC","data":{"p":"10"}}`))
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-p.Events():
+			v, err := ev.Decode()
+			if trade, ok := v.(TradeEvent); err != nil || !ok || trade.Price.String() != "10" {
+				t.Errorf("Decode = %+v, %v, want TradeEvent", v, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("event not delivered")
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ListenAndServe = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenAndServe did not return")
+	}
+	if _, ok := <-p.Events(); ok {
+		t.Error("Events not closed after ListenAndServe")
+	}
+}
+
+func TestEventDecode(t *testing.T) {
+	tests := []struct {
+		stream string
+		want   interface{}
+	}{
+		{"depth.SOL_USDC", DepthEvent{}},
+		{"trade.SOL_USDC", TradeEvent{}},
+		{"ticker.SOL_USDC", TickerEvent{}},
+		{"bookTicker.SOL_USDC", BookTickerEvent{}},
+		{"kline.1m.SOL_USDC", KlineEvent{}},
+		{"account.orderUpdate.SOL_USDC", OrderUpdate{}},
+	}
+	for _, tt := range tests {
+		got, err := Event{Stream: tt.stream, Data: []byte(`{}`)}.Decode()
+		if err != nil || reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+			t.Errorf("Decode(%s) = %T, %v, want %T", tt.stream, got, err, tt.want)
+		}
+	}
+	if _, err := (Event{Stream: "account.positionUpdate", Data: []byte(`{}`)}).Decode(); err == nil {
+		t.Error("Decode unknown stream: want error")
+	}
+}
@@ -0,0 +1,258 @@
+package backpack_websocket
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"backpack_api/backpack_interface"
+)
+
+const (
+	// pnlScale 为计算均价时保留的小数位数
+	pnlScale = 12
+	// maxSeenTrades 为用于去重的最近成交数，历史和推送只在启动时短暂重叠
+	maxSeenTrades = 10000
+)
+
+// Position 为一个交易对的持仓和盈亏。
+// 盈亏以该交易对的计价资产表示，手续费换算为 Portfolio 的计价资产。
+type Position struct {
+	Symbol        string
+	Quantity      backpack_interface.Decimal // 净持仓，正数为多头，负数为空头
+	AvgEntry      backpack_interface.Decimal // 持仓均价，无持仓时为 0
+	RealizedPnL   backpack_interface.Decimal // 已实现盈亏，不含手续费
+	UnrealizedPnL backpack_interface.Decimal // 按 Mark 计算的浮动盈亏
+	Mark          backpack_interface.Decimal // 标记价格，没有行情时为 0
+	Fees          backpack_interface.Decimal // 已换算为计价资产的手续费
+	Fills         int
+}
+
+// Portfolio 由历史成交和订单更新推送的成交计算持仓、均价、已实现和浮动盈亏。
+// 标记价格来自 ticker 推送、订单簿中间价或 SetMark。
+// 最近 maxSeenTrades 笔成交中的同一笔（按交易对和成交 ID）只计一次，历史和推送可以重叠。
+type Portfolio struct {
+	quote string
+
+	mu        sync.RWMutex
+	positions map[string]*Position
+	marks     map[string]backpack_interface.Decimal
+	books     map[string]*OrderBook
+	seen      map[string]bool
+	seenOrder []string // seen 中的成交，按计入顺序，超过 maxSeenTrades 时删除最早的
+	// 无法换算的手续费，按资产记录
+	unconverted map[string]backpack_interface.Decimal
+}
+
+// NewPortfolio 创建以 quote（例如 USDC）为计价资产的投资组合
+func NewPortfolio(quote string) *Portfolio {
+	return &Portfolio{
+		quote:       quote,
+		positions:   make(map[string]*Position),
+		marks:       make(map[string]backpack_interface.Decimal),
+		books:       make(map[string]*OrderBook),
+		seen:        make(map[string]bool),
+		unconverted: make(map[string]backpack_interface.Decimal),
+	}
+}
+
+// Attach 在 ws 上注册订单更新处理函数并订阅 account.orderUpdate，ws 需要配置签名器
+func (p *Portfolio) Attach(ctx context.Context, ws *WebSocketClient) error {
+	ws.OnOrderUpdate(p.ApplyOrderUpdate)
+	return ws.SubscribePrivate(ctx, "account.orderUpdate")
+}
+
+// MarkWithTicker 用 ticker.<symbol> 推送的最新价作为标记价格
+func (p *Portfolio) MarkWithTicker(ctx context.Context, ws *WebSocketClient, symbol string) error {
+	ws.OnTicker(symbol, func(ev TickerEvent) { p.SetMark(ev.Symbol, ev.Close) })
+	return ws.Subscribe(ctx, "ticker."+symbol)
+}
+
+// MarkWithOrderBook 用订单簿的中间价作为标记价格，优先于 SetMark 和 ticker
+func (p *Portfolio) MarkWithOrderBook(ob *OrderBook) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.books[ob.Symbol()] = ob
+}
+
+// SetMark 设置 symbol 的标记价格
+func (p *Portfolio) SetMark(symbol string, price backpack_interface.Decimal) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.marks[symbol] = price
+}
+
+// LoadFills 读取并计入历史成交，按时间顺序应用
+func (p *Portfolio) LoadFills(ctx context.Context, rest *backpack_interface.Client, f backpack_interface.FillFilter) error {
+	fills, err := rest.FillsIter(ctx, f).All()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Timestamp < fills[j].Timestamp })
+	for _, fill := range fills {
+		p.ApplyFill(fill)
+	}
+	return nil
+}
+
+// ApplyFill 计入一条历史成交
+func (p *Portfolio) ApplyFill(f backpack_interface.Fill) {
+	p.apply(f.Symbol, strconv.FormatInt(f.TradeID, 10), f.Side, f.Price, f.Quantity, f.Fee, f.FeeSymbol)
+}
+
+// ApplyOrderUpdate 计入订单更新推送中的成交，没有成交数量的推送被忽略
+func (p *Portfolio) ApplyOrderUpdate(ev OrderUpdate) {
+	if ev.FillQuantity.IsZero() {
+		return
+	}
+	p.apply(ev.Symbol, ev.TradeID, ev.Side, ev.FillPrice, ev.FillQuantity, ev.Fee, ev.FeeSymbol)
+}
+
+func (p *Portfolio) apply(
+	symbol, tradeID string,
+	side backpack_interface.Side,
+	price, quantity, fee backpack_interface.Decimal,
+	feeSymbol string,
+) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if tradeID != "" {
+		key := symbol + "/" + tradeID
+		if p.seen[key] {
+			return
+		}
+		p.seen[key] = true
+		p.seenOrder = append(p.seenOrder, key)
+		if len(p.seenOrder) > maxSeenTrades {
+			delete(p.seen, p.seenOrder[0])
+			p.seenOrder = p.seenOrder[1:]
+		}
+	}
+
+	pos := p.positions[symbol]
+	if pos == nil {
+		pos = &Position{Symbol: symbol}
+		p.positions[symbol] = pos
+	}
+	pos.Fills++
+
+	delta := quantity
+	if side == backpack_interface.SideAsk {
+		delta = quantity.Neg()
+	}
+	held := pos.Quantity
+	switch {
+	case held.IsZero() || held.Sign() == delta.Sign():
+		// 加仓：按数量加权更新均价
+		cost := pos.AvgEntry.Mul(held.Abs()).Add(price.Mul(quantity))
+		pos.Quantity = held.Add(delta)
+		pos.AvgEntry = cost.Quo(pos.Quantity.Abs(), pnlScale).Normalize()
+	default:
+		// 减仓或反手：平掉的部分按均价实现盈亏
+		closed := quantity
+		if held.Abs().Cmp(quantity) < 0 {
+			closed = held.Abs()
+		}
+		pnl := price.Sub(pos.AvgEntry).Mul(closed)
+		if held.Sign() < 0 {
+			pnl = pnl.Neg()
+		}
+		pos.RealizedPnL = pos.RealizedPnL.Add(pnl).Normalize()
+		pos.Quantity = held.Add(delta)
+		switch {
+		case pos.Quantity.IsZero():
+			pos.AvgEntry = backpack_interface.Decimal{}
+		case pos.Quantity.Sign() != held.Sign():
+			pos.AvgEntry = price
+		}
+	}
+
+	p.addFee(pos, fee, feeSymbol, price)
+}
+
+// addFee 把手续费换算为计价资产：手续费为计价资产时直接计入，
+// 为该交易对的基础资产时按成交价换算，否则按 <资产>_<计价资产> 的标记价格换算
+func (p *Portfolio) addFee(pos *Position, fee backpack_interface.Decimal, feeSymbol string, price backpack_interface.Decimal) {
+	if fee.IsZero() {
+		return
+	}
+	base, quote := splitSymbol(pos.Symbol)
+	switch {
+	case feeSymbol == p.quote:
+		pos.Fees = pos.Fees.Add(fee)
+	case feeSymbol == base && quote == p.quote:
+		pos.Fees = pos.Fees.Add(fee.Mul(price))
+	default:
+		if mark, ok := p.mark(feeSymbol + "_" + p.quote); ok {
+			pos.Fees = pos.Fees.Add(fee.Mul(mark))
+		} else {
+			p.unconverted[feeSymbol] = p.unconverted[feeSymbol].Add(fee)
+		}
+	}
+}
+
+// mark 返回 symbol 的标记价格，必须持有锁
+func (p *Portfolio) mark(symbol string) (backpack_interface.Decimal, bool) {
+	if ob, ok := p.books[symbol]; ok {
+		if mid, ok := ob.Mid(); ok {
+			return mid, true
+		}
+	}
+	mark, ok := p.marks[symbol]
+	return mark, ok && !mark.IsZero()
+}
+
+// Position 返回 symbol 的持仓，浮动盈亏按当前标记价格计算
+func (p *Portfolio) Position(symbol string) (Position, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	pos, ok := p.positions[symbol]
+	if !ok {
+		return Position{}, false
+	}
+	return p.valued(pos), true
+}
+
+// Positions 返回所有持仓，按交易对排序
+func (p *Portfolio) Positions() []Position {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	rst := make([]Position, 0, len(p.positions))
+	for _, pos := range p.positions {
+		rst = append(rst, p.valued(pos))
+	}
+	sort.Slice(rst, func(i, j int) bool { return rst[i].Symbol < rst[j].Symbol })
+	return rst
+}
+
+// UnconvertedFees 返回没有标记价格而无法换算的手续费，按资产汇总
+func (p *Portfolio) UnconvertedFees() map[string]backpack_interface.Decimal {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	rst := make(map[string]backpack_interface.Decimal, len(p.unconverted))
+	for asset, fee := range p.unconverted {
+		rst[asset] = fee
+	}
+	return rst
+}
+
+// valued 返回带浮动盈亏的持仓副本，必须持有锁
+func (p *Portfolio) valued(pos *Position) Position {
+	v := *pos
+	if mark, ok := p.mark(pos.Symbol); ok {
+		v.Mark = mark
+		v.UnrealizedPnL = mark.Sub(pos.AvgEntry).Mul(pos.Quantity)
+	}
+	return v
+}
+
+// splitSymbol 把 SOL_USDC 拆成 SOL 和 USDC
+func splitSymbol(symbol string) (base, quote string) {
+	if i := strings.LastIndex(symbol, "_"); i >= 0 {
+		return symbol[:i], symbol[i+1:]
+	}
+	return symbol, ""
+}
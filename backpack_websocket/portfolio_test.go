@@ -0,0 +1,174 @@
+package backpack_websocket
+
+import (
+	"strconv"
+	"testing"
+
+	"backpack_api/backpack_interface"
+)
+
+// testFill 创建一条 SOL_USDC 的历史成交，手续费为 0
+func testFill(id int64, side backpack_interface.Side, price, quantity string) backpack_interface.Fill {
+	return backpack_interface.Fill{
+		TradeID:  id,
+		Symbol:   "SOL_USDC",
+		Side:     side,
+		Price:    backpack_interface.MustDecimal(price),
+		Quantity: backpack_interface.MustDecimal(quantity),
+	}
+}
+
+// checkPosition 比较持仓的数量、均价、已实现和浮动盈亏
+func checkPosition(t *testing.T, p *Portfolio, step, quantity, avg, realized, unrealized string) {
+	t.Helper()
+	pos, ok := p.Position("SOL_USDC")
+	if !ok {
+		t.Fatalf("%s: no position", step)
+	}
+	d := backpack_interface.MustDecimal
+	if !pos.Quantity.Equal(d(quantity)) || !pos.AvgEntry.Equal(d(avg)) ||
+		!pos.RealizedPnL.Equal(d(realized)) || !pos.UnrealizedPnL.Equal(d(unrealized)) {
+		t.Fatalf("%s: quantity %s avg %s realized %s unrealized %s, want %s %s %s %s", step,
+			pos.Quantity, pos.AvgEntry, pos.RealizedPnL, pos.UnrealizedPnL, quantity, avg, realized, unrealized)
+	}
+}
+
+func TestPortfolioLong(t *testing.T) {
+	bid, ask := backpack_interface.SideBid, backpack_interface.SideAsk
+	p := NewPortfolio("USDC")
+
+	p.ApplyFill(testFill(1, bid, "10", "2"))
+	p.ApplyFill(testFill(2, bid, "12", "2"))
+	checkPosition(t, p, "buy 2@10, 2@12", "4", "11", "0", "0")
+
+	p.SetMark("SOL_USDC", backpack_interface.MustDecimal("13"))
+	checkPosition(t, p, "mark 13", "4", "11", "0", "8")
+
+	// 部分平仓按均价实现盈亏，剩余持仓均价不变
+	p.ApplyFill(testFill(3, ask, "15", "1"))
+	checkPosition(t, p, "sell 1@15", "3", "11", "4", "6")
+
+	// 反手：平掉 3 个实现 (9-11)*3，剩余 2 个空头以成交价为均价
+	p.ApplyFill(testFill(4, ask, "9", "5"))
+	checkPosition(t, p, "sell 5@9", "-2", "9", "-2", "-8")
+
+	// 空头在价格低于均价时盈利
+	p.SetMark("SOL_USDC", backpack_interface.MustDecimal("8"))
+	checkPosition(t, p, "mark 8", "-2", "9", "-2", "2")
+
+	p.ApplyFill(testFill(5, bid, "10", "2"))
+	checkPosition(t, p, "buy 2@10", "0", "0", "-4", "0")
+}
+
+func TestPortfolioShort(t *testing.T) {
+	bid, ask := backpack_interface.SideBid, backpack_interface.SideAsk
+	p := NewPortfolio("USDC")
+
+	p.ApplyFill(testFill(1, ask, "100", "2"))
+	p.ApplyFill(testFill(2, ask, "110", "2"))
+	p.SetMark("SOL_USDC", backpack_interface.MustDecimal("100"))
+	checkPosition(t, p, "sell 2@100, 2@110", "-4", "105", "0", "20")
+
+	p.ApplyFill(testFill(3, bid, "95", "1"))
+	checkPosition(t, p, "buy 1@95", "-3", "105", "10", "15")
+
+	p.ApplyFill(testFill(4, bid, "120", "1"))
+	checkPosition(t, p, "buy 1@120", "-2", "105", "-5", "10")
+
+	// 反手为多头
+	p.ApplyFill(testFill(5, bid, "90", "3"))
+	checkPosition(t, p, "buy 3@90", "1", "90", "25", "10")
+}
+
+func TestPortfolioAverageEntryPrecision(t *testing.T) {
+	p := NewPortfolio("USDC")
+	p.ApplyFill(testFill(1, backpack_interface.SideBid, "10", "1"))
+	p.ApplyFill(testFill(2, backpack_interface.SideBid, "11", "2"))
+	pos, _ := p.Position("SOL_USDC")
+	if got := pos.AvgEntry.String(); got != "10.666666666667" {
+		t.Errorf("AvgEntry = %s, want 10.666666666667", got)
+	}
+}
+
+func TestPortfolioFees(t *testing.T) {
+	d := backpack_interface.MustDecimal
+	p := NewPortfolio("USDC")
+	p.SetMark("PYTH_USDC", d("0.5"))
+	fill := func(id int64, fee, feeSymbol string) backpack_interface.Fill {
+		f := testFill(id, backpack_interface.SideBid, "20", "1")
+		f.Fee, f.FeeSymbol = d(fee), feeSymbol
+		return f
+	}
+
+	p.ApplyFill(fill(1, "0.1", "USDC")) // 计价资产：直接计入
+	p.ApplyFill(fill(2, "0.01", "SOL")) // 基础资产：按成交价 20 换算
+	p.ApplyFill(fill(3, "2", "PYTH"))   // 其他资产：按 PYTH_USDC 标记价格换算
+	p.ApplyFill(fill(4, "3", "JTO"))    // 没有标记价格：记为未换算
+	p.ApplyFill(fill(5, "0.4", "JTO"))
+
+	pos, _ := p.Position("SOL_USDC")
+	if !pos.Fees.Equal(d("1.3")) {
+		t.Errorf("Fees = %s, want 1.3", pos.Fees)
+	}
+	unconverted := p.UnconvertedFees()
+	if len(unconverted) != 1 || !unconverted["JTO"].Equal(d("3.4")) {
+		t.Errorf("UnconvertedFees = %v, want JTO 3.4", unconverted)
+	}
+	// 手续费不计入已实现盈亏
+	if !pos.RealizedPnL.IsZero() {
+		t.Errorf("RealizedPnL = %s, want 0", pos.RealizedPnL)
+	}
+}
+
+func TestPortfolioBaseFeeOtherQuote(t *testing.T) {
+	// SOL_BTC 的 SOL 手续费不能按以 BTC 计价的成交价换算成 USDC
+	d := backpack_interface.MustDecimal
+	p := NewPortfolio("USDC")
+	p.SetMark("SOL_USDC", d("150"))
+	p.ApplyFill(backpack_interface.Fill{TradeID: 1, Symbol: "SOL_BTC", Side: backpack_interface.SideBid,
+		Price: d("0.002"), Quantity: d("1"), Fee: d("0.01"), FeeSymbol: "SOL"})
+	pos, _ := p.Position("SOL_BTC")
+	if !pos.Fees.Equal(d("1.5")) {
+		t.Errorf("Fees = %s, want 1.5", pos.Fees)
+	}
+}
+
+func TestPortfolioDeduplicatesFills(t *testing.T) {
+	d := backpack_interface.MustDecimal
+	p := NewPortfolio("USDC")
+	p.ApplyFill(testFill(42, backpack_interface.SideBid, "10", "2"))
+	// 同一笔成交从推送再次到达
+	p.ApplyOrderUpdate(OrderUpdate{Symbol: "SOL_USDC", TradeID: "42", Side: backpack_interface.SideBid,
+		FillPrice: d("10"), FillQuantity: d("2"), Fee: d("0.1"), FeeSymbol: "USDC"})
+	// 没有成交数量的推送被忽略
+	p.ApplyOrderUpdate(OrderUpdate{Symbol: "SOL_USDC", TradeID: "43", Side: backpack_interface.SideBid,
+		OrderState: backpack_interface.OrderStatusNew})
+	// 其他交易对的同一成交 ID 是另一笔成交
+	p.ApplyOrderUpdate(OrderUpdate{Symbol: "BTC_USDC", TradeID: "42", Side: backpack_interface.SideAsk,
+		FillPrice: d("60000"), FillQuantity: d("0.1")})
+
+	pos, _ := p.Position("SOL_USDC")
+	if pos.Fills != 1 || !pos.Quantity.Equal(d("2")) || !pos.Fees.IsZero() {
+		t.Errorf("SOL_USDC = %+v, want one fill of 2", pos)
+	}
+	if btc, ok := p.Position("BTC_USDC"); !ok || !btc.Quantity.Equal(d("-0.1")) {
+		t.Errorf("BTC_USDC = %+v, want -0.1", btc)
+	}
+	if n := len(p.Positions()); n != 2 {
+		t.Errorf("positions = %d, want 2", n)
+	}
+}
+
+func TestPortfolioSeenBounded(t *testing.T) {
+	p := NewPortfolio("USDC")
+	for i := 0; i <= maxSeenTrades; i++ {
+		p.ApplyOrderUpdate(OrderUpdate{Symbol: "SOL_USDC", TradeID: strconv.Itoa(i), Side: backpack_interface.SideBid,
+			FillPrice: backpack_interface.MustDecimal("1"), FillQuantity: backpack_interface.MustDecimal("1")})
+	}
+	if len(p.seen) != maxSeenTrades || len(p.seenOrder) != maxSeenTrades {
+		t.Fatalf("seen = %d/%d, want %d", len(p.seen), len(p.seenOrder), maxSeenTrades)
+	}
+	if p.seen["SOL_USDC/0"] || !p.seen["SOL_USDC/"+strconv.Itoa(maxSeenTrades)] {
+		t.Error("oldest trade should be evicted first")
+	}
+}
@@ -0,0 +1,23 @@
+package main // 声明 main 包，表明当前是一个可执行程序
+
+import (
+	"context"
+	"fmt"
+
+	"backpack_api/backpack_interface"
+)
+
+func main() {
+	client, err := backpack_interface.NewClient(backpack_interface.Key{})
+	if err != nil {
+		panic(err)
+	}
+
+	systemTime, err := client.GetSystemTime(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	// 输出系统时间
+	fmt.Println("System Time:", systemTime)
+}
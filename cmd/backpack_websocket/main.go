@@ -0,0 +1,75 @@
+package main // 声明 main 包，表明当前是一个可执行程序
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	config "backpack_api"
+	"backpack_api/backpack_interface"
+	"backpack_api/backpack_websocket"
+)
+
+// processOrderUpdate处理订单更新事件
+func processOrderUpdate(orderUpdate backpack_websocket.OrderUpdate) {
+	// Print or process the order update event as needed
+	fmt.Printf("Received order update event: %+v\n", orderUpdate)
+}
+
+func main() {
+	// 收到中断信号时取消 ctx，订单簿的后台同步随之停止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// 配置了密钥时订阅订单更新
+	var opts []backpack_websocket.Option
+	if cfg, err := config.ReadConfig("config.json"); err == nil {
+		signer, err := backpack_interface.NewSigner(cfg.APIKey, cfg.SecretKey)
+		if err == nil {
+			opts = append(opts, backpack_websocket.WithSigner(signer))
+		} else {
+			log.Println("Skipping private streams:", err)
+		}
+	}
+
+	// 创建WebSocket客户端
+	client := backpack_websocket.NewWebSocketClient(append(opts,
+		backpack_websocket.WithStateHandler(func(ev backpack_websocket.StateEvent) {
+			log.Printf("WebSocket %s (attempt %d, err %v)\n", ev.State, ev.Attempt, ev.Err)
+		}),
+	)...)
+
+	// 维护 SOL_USDC 的本地订单簿，订阅在连接建立后发送，重连后自动重放
+	rest, err := backpack_interface.NewClient(backpack_interface.Key{})
+	if err != nil {
+		panic(err)
+	}
+	book, err := backpack_websocket.NewOrderBook(ctx, client, rest, "SOL_USDC")
+	if err != nil {
+		panic(err)
+	}
+	client.OnDepth("SOL_USDC", func(ev backpack_websocket.DepthEvent) {
+		bid, _ := book.BestBid()
+		ask, _ := book.BestAsk()
+		log.Printf("SOL_USDC bid %s x %s, ask %s x %s\n", bid.Price, bid.Quantity, ask.Price, ask.Quantity)
+	})
+	client.OnOrderUpdate(processOrderUpdate)
+	if err := client.SubscribePrivate(ctx, "account.orderUpdate"); err != nil {
+		log.Println("Skipping account.orderUpdate:", err)
+	}
+
+	// 监听传入的消息，直到客户端关闭
+	go client.ListenAndServe(context.Background())
+
+	// 等待中断信号后优雅关闭
+	<-ctx.Done()
+	log.Println("Received interrupt signal, closing WebSocket connection...")
+	closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Close(closeCtx); err != nil {
+		log.Println("Close:", err)
+	}
+}